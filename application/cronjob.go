@@ -1,25 +1,37 @@
 package application
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
 type CronJobService struct {
-	cron     *cron.Cron
-	fetcher  *HotelFetcher
-	entryID  cron.EntryID
-	interval string
+	cron         *cron.Cron
+	fetcher      *HotelFetcher
+	entryID      cron.EntryID
+	interval     string
+	imageEntryID cron.EntryID
+	ctx          context.Context
+	cancel       context.CancelFunc
+
+	// runMu serialises fetches and image health checks, including the
+	// startup fetch run outside the scheduler.
+	runMu sync.Mutex
 }
 
 func NewCronJobService(fetcher *HotelFetcher, interval string) *CronJobService {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &CronJobService{
-		cron:     cron.New(cron.WithSeconds()),
+		cron:     cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger))),
 		fetcher:  fetcher,
 		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 }
 
@@ -39,9 +51,28 @@ func (cs *CronJobService) Start() error {
 	return nil
 }
 
+func (cs *CronJobService) ScheduleImageHealthCheck(interval string) error {
+	entryID, err := cs.cron.AddFunc(interval, func() {
+		cs.runMu.Lock()
+		defer cs.runMu.Unlock()
+
+		if err := cs.fetcher.CheckImageHealth(cs.ctx); err != nil {
+			log.Printf("Image health check failed: %v", err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add image health check job: %w", err)
+	}
+
+	cs.imageEntryID = entryID
+	return nil
+}
+
+// Stop cancels the context of running jobs and waits for them to return.
 func (cs *CronJobService) Stop() {
+	cs.cancel()
 	if cs.cron != nil {
-		cs.cron.Stop()
+		<-cs.cron.Stop().Done()
 	}
 }
 
@@ -50,7 +81,10 @@ func (cs *CronJobService) RunOnce() error {
 }
 
 func (cs *CronJobService) fetchJob() error {
-	if err := cs.fetcher.FetchAndProcess(); err != nil {
+	cs.runMu.Lock()
+	defer cs.runMu.Unlock()
+
+	if err := cs.fetcher.FetchAndProcess(cs.ctx); err != nil {
 		log.Printf("Scheduled hotel fetch failed: %v", err)
 		return err
 	}
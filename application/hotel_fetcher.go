@@ -3,9 +3,13 @@ package application
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,24 +17,84 @@ import (
 )
 
 type HotelFetcher struct {
-	repository   domain.HotelRepository
-	client       *http.Client
-	supplierURLs []string
+	repository      domain.Repository
+	client          *http.Client
+	supplierURLs    []string
+	imageChecker    *ImageHealthChecker
+	matchThreshold  float64
+	ratingRule      string
+	dedupeThreshold float64
+	destinationRefs map[int]*domain.Destination
+	qualityModel    domain.QualityModel
+	maxVersions     int
+	reconcile       bool
+	reviewScales    map[string]float64
 }
 
-func NewHotelFetcher(repository domain.HotelRepository, supplierURLs []string) *HotelFetcher {
+func NewHotelFetcher(repository domain.Repository, supplierURLs []string) *HotelFetcher {
 	return &HotelFetcher{
 		repository: repository,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		supplierURLs: supplierURLs,
+		supplierURLs:   supplierURLs,
+		matchThreshold: 0.8,
+		ratingRule:     domain.RatingMergeWeighted,
+		qualityModel:   domain.DefaultQualityModel(),
+		maxVersions:    10,
 	}
 }
 
-func (hf *HotelFetcher) FetchAndProcess() error {
-	log.Println("Starting hotel data fetch from suppliers...")
+func (hf *HotelFetcher) SetImageHealthChecker(checker *ImageHealthChecker) {
+	hf.imageChecker = checker
+}
+
+func (hf *HotelFetcher) SetDuplicateMatchThreshold(threshold float64) {
+	hf.matchThreshold = threshold
+}
+
+func (hf *HotelFetcher) SetRatingMergeRule(rule string) {
+	hf.ratingRule = rule
+}
+
+func (hf *HotelFetcher) SetNearDuplicateThreshold(threshold float64) {
+	hf.dedupeThreshold = threshold
+}
+
+func (hf *HotelFetcher) SetDestinationReference(destinations []*domain.Destination) {
+	hf.destinationRefs = make(map[int]*domain.Destination, len(destinations))
+	for _, destination := range destinations {
+		hf.destinationRefs[destination.DestinationID] = destination
+	}
+}
+
+func (hf *HotelFetcher) SetQualityModel(model domain.QualityModel) {
+	hf.qualityModel = model
+}
+
+func (hf *HotelFetcher) SetMaxVersions(maxVersions int) {
+	hf.maxVersions = maxVersions
+}
+
+// SetReviewScales sets the review score scale of each supplier, by supplier
+// name, used for scores delivered without one.
+func (hf *HotelFetcher) SetReviewScales(scales map[string]float64) {
+	hf.reviewScales = make(map[string]float64, len(scales))
+	for supplier, scale := range scales {
+		hf.reviewScales[strings.ToLower(supplier)] = scale
+	}
+}
+
+// SetReconcile makes a run that fetched from every supplier delete the stored
+// hotels no supplier returned, for stores that never expire hotels.
+func (hf *HotelFetcher) SetReconcile(reconcile bool) {
+	hf.reconcile = reconcile
+}
+
+func (hf *HotelFetcher) FetchAndProcess(ctx context.Context) error {
 	startTime := time.Now()
+	runID := domain.NewRunID(startTime)
+	log.Printf("Starting hotel data fetch from suppliers (run %s)...", runID)
 
 	hotelsBySupplier := make(map[string][]*domain.Hotel)
 	var wg sync.WaitGroup
@@ -42,7 +106,7 @@ func (hf *HotelFetcher) FetchAndProcess() error {
 		go func(supplierURL string) {
 			defer wg.Done()
 
-			hotels, err := hf.fetchFromSupplier(supplierURL)
+			hotels, err := hf.fetchFromSupplier(ctx, supplierURL)
 			if err != nil {
 				log.Printf("Failed to fetch from %s: %v", supplierURL, err)
 				mu.Lock()
@@ -65,21 +129,108 @@ func (hf *HotelFetcher) FetchAndProcess() error {
 		return fmt.Errorf("no data fetched from any supplier")
 	}
 
-	mergedHotels := hf.mergeHotelsByID(hotelsBySupplier)
+	if err := hf.applySupplierCrosswalk(ctx, hotelsBySupplier); err != nil {
+		log.Printf("Supplier crosswalk failed, using native IDs: %v", err)
+	}
+
+	crosswalk, err := hf.repository.GetApprovedDuplicates(ctx)
+	if err != nil {
+		log.Printf("Failed to load approved duplicates, merging by exact ID only: %v", err)
+		crosswalk = domain.HotelIDCrosswalk{}
+	}
 
-	if err := hf.storeHotels(mergedHotels); err != nil {
-		return fmt.Errorf("failed to store hotels: %w", err)
+	mergedHotels := hf.mergeHotelsByID(hotelsBySupplier, crosswalk)
+	for _, hotel := range mergedHotels {
+		hotel.ResolveRating(hf.ratingRule)
+		if hf.dedupeThreshold > 0 {
+			for _, decision := range hotel.CollapseNearDuplicates(hf.dedupeThreshold) {
+				log.Printf("Collapsed near-duplicate %s for hotel %s (similarity %.2f): kept %q, dropped %q",
+					decision.Field, hotel.HotelID, decision.Similarity, decision.Kept, decision.Dropped)
+			}
+		}
+	}
+
+	if hf.imageChecker != nil {
+		cached := hf.imageChecker.Cached()
+		for _, hotel := range mergedHotels {
+			hotel.Images.ApplyHealth(cached)
+			hotel.PrimaryImage = hotel.Images.Primary()
+		}
+	}
+
+	// A partly failed write is reported once the run has finished, since the
+	// stored hotels are still worth matching.
+	storeErr := hf.storeHotels(ctx, mergedHotels, runID, len(fetchErrors) == 0)
+
+	if err := hf.detectDuplicates(ctx, mergedHotels, crosswalk); err != nil {
+		log.Printf("Duplicate detection failed: %v", err)
+	}
+
+	// Only a complete run shows which hotels are gone; after a supplier or
+	// store failure the missing hotels may simply not have been seen.
+	if hf.reconcile && len(fetchErrors) == 0 && storeErr == nil {
+		hotelIDs := make([]string, 0, len(mergedHotels))
+		for hotelID := range mergedHotels {
+			hotelIDs = append(hotelIDs, hotelID)
+		}
+		if _, err := hf.repository.DeleteHotelsExcept(ctx, hotelIDs); err != nil {
+			log.Printf("Reconciliation failed: %v", err)
+		}
 	}
 
 	duration := time.Since(startTime)
 	log.Printf("Hotel data processing completed in %v. Processed %d hotels from %d suppliers",
 		duration, len(mergedHotels), len(hotelsBySupplier))
 
+	if storeErr != nil {
+		return fmt.Errorf("failed to store hotels: %w", storeErr)
+	}
+
+	return nil
+}
+
+// CheckImageHealth checks the image links of the stored hotels and caches the
+// results. It never writes hotels itself: the next fetch applies the cached
+// health to the hotels it stores, so a slow check cannot overwrite fresher
+// supplier data with the snapshot it started from.
+func (hf *HotelFetcher) CheckImageHealth(ctx context.Context) error {
+	if hf.imageChecker == nil {
+		return nil
+	}
+
+	startTime := time.Now()
+	log.Printf("Starting image link health check...")
+
+	hotels, err := hf.repository.GetAllHotels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load hotels: %w", err)
+	}
+
+	var links []string
+	for _, hotel := range hotels {
+		links = append(links, hotel.Images.Links()...)
+	}
+
+	health := hf.imageChecker.Check(ctx, links)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("image health check interrupted: %w", err)
+	}
+
+	dead := 0
+	for _, status := range health {
+		if !status.Alive {
+			dead++
+		}
+	}
+
+	log.Printf("Image health check completed in %v. Checked %d links, %d dead",
+		time.Since(startTime), len(health), dead)
+
 	return nil
 }
 
-func (hf *HotelFetcher) fetchFromSupplier(url string) ([]*domain.Hotel, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (hf *HotelFetcher) fetchFromSupplier(ctx context.Context, url string) ([]*domain.Hotel, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -102,14 +253,57 @@ func (hf *HotelFetcher) fetchFromSupplier(url string) ([]*domain.Hotel, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	supplier := SupplierName(url)
 	for _, hotel := range hotels {
+		if !hotel.Rating.ApplyReviewScale(hf.reviewScales[supplier]) {
+			log.Printf("Dropped review score of hotel %s from %s: no review scale configured for the supplier",
+				hotel.HotelID, supplier)
+		}
 		hotel.CleanData()
+		hotel.SetSource(supplier)
 	}
 
 	return hotels, nil
 }
 
-func (hf *HotelFetcher) mergeHotelsByID(hotelsBySupplier map[string][]*domain.Hotel) map[string]*domain.Hotel {
+func (hf *HotelFetcher) applySupplierCrosswalk(ctx context.Context, hotelsBySupplier map[string][]*domain.Hotel) error {
+	entries, err := hf.repository.GetCrosswalkEntries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load crosswalk entries: %w", err)
+	}
+
+	crosswalk := domain.NewSupplierCrosswalk(entries)
+	report := domain.CrosswalkReport{
+		RunAt:    time.Now(),
+		Unmapped: make(map[string]domain.UnmappedIDs),
+	}
+
+	for supplierURL, hotels := range hotelsBySupplier {
+		supplier := SupplierName(supplierURL)
+		unmapped := crosswalk.Apply(supplier, hotels)
+		if len(unmapped.HotelIDs) > 0 || len(unmapped.DestinationIDs) > 0 {
+			log.Printf("Supplier %s has %d unmapped hotel IDs and %d unmapped destination IDs",
+				supplier, len(unmapped.HotelIDs), len(unmapped.DestinationIDs))
+			report.Unmapped[supplier] = unmapped
+		}
+	}
+
+	if err := hf.repository.StoreCrosswalkReport(ctx, report); err != nil {
+		return fmt.Errorf("failed to store crosswalk report: %w", err)
+	}
+
+	return nil
+}
+
+func SupplierName(supplierURL string) string {
+	parsed, err := url.Parse(supplierURL)
+	if err != nil || parsed.Path == "" {
+		return strings.ToLower(supplierURL)
+	}
+	return strings.ToLower(path.Base(strings.TrimRight(parsed.Path, "/")))
+}
+
+func (hf *HotelFetcher) mergeHotelsByID(hotelsBySupplier map[string][]*domain.Hotel, crosswalk domain.HotelIDCrosswalk) map[string]*domain.Hotel {
 	mergedHotels := make(map[string]*domain.Hotel)
 
 	for supplierURL, hotels := range hotelsBySupplier {
@@ -119,6 +313,11 @@ func (hf *HotelFetcher) mergeHotelsByID(hotelsBySupplier map[string][]*domain.Ho
 				continue
 			}
 
+			if canonicalID := crosswalk.Resolve(hotel.HotelID); canonicalID != hotel.HotelID {
+				log.Printf("Mapped duplicate hotel %s to %s from %s", hotel.HotelID, canonicalID, supplierURL)
+				hotel.HotelID = canonicalID
+			}
+
 			if existing, exists := mergedHotels[hotel.HotelID]; exists {
 				existing.MergeWith(hotel)
 				log.Printf("Merged hotel %s from %s", hotel.HotelID, supplierURL)
@@ -132,27 +331,128 @@ func (hf *HotelFetcher) mergeHotelsByID(hotelsBySupplier map[string][]*domain.Ho
 	return mergedHotels
 }
 
-func (hf *HotelFetcher) storeHotels(hotels map[string]*domain.Hotel) error {
-	hotelsByDestination := make(map[int][]*domain.Hotel)
+func (hf *HotelFetcher) detectDuplicates(ctx context.Context, hotels map[string]*domain.Hotel, crosswalk domain.HotelIDCrosswalk) error {
+	skip, err := hf.repository.GetRejectedDuplicates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load rejected duplicates: %w", err)
+	}
+	for duplicateID, hotelID := range crosswalk {
+		skip[domain.DuplicatePairKey(hotelID, duplicateID)] = true
+	}
+
+	hotelList := make([]*domain.Hotel, 0, len(hotels))
+	for _, hotel := range hotels {
+		hotelList = append(hotelList, hotel)
+	}
+
+	candidates := domain.FindDuplicateCandidates(hotelList, hf.matchThreshold, skip)
+	if err := hf.repository.StoreDuplicateCandidates(ctx, candidates); err != nil {
+		return fmt.Errorf("failed to store duplicate candidates: %w", err)
+	}
+
+	return nil
+}
+
+func (hf *HotelFetcher) recordVersion(ctx context.Context, hotel *domain.Hotel, runID string) error {
+	_, err := hf.repository.AppendHotelVersion(ctx, domain.HotelVersion{
+		RunID:       runID,
+		RecordedAt:  time.Now(),
+		Fingerprint: hotel.Fingerprint(),
+		Hotel:       hotel,
+	}, hf.maxVersions)
+	return err
+}
+
+// storeHotels stores hotels and the reports derived from them. Destinations
+// are only rebuilt when complete, i.e. every supplier was fetched: their hotel
+// counts and facets would otherwise shrink to the suppliers that answered.
+func (hf *HotelFetcher) storeHotels(ctx context.Context, hotels map[string]*domain.Hotel, runID string, complete bool) error {
+	var validHotels []*domain.Hotel
+	var qualityScores []float64
 
 	for _, hotel := range hotels {
 		if err := hotel.Validate(); err != nil {
 			log.Printf("Skipping invalid hotel %s: %v", hotel.HotelID, err)
 			continue
 		}
+		validHotels = append(validHotels, hotel)
+
+		quality := hf.qualityModel.Score(hotel)
+		hotel.Quality = &quality
+		qualityScores = append(qualityScores, quality.Score)
+	}
 
-		if err := hf.repository.StoreHotelByID(hotel.HotelID, hotel); err != nil {
-			log.Printf("Failed to store hotel %s: %v", hotel.HotelID, err)
+	changed, touched := hf.refreshUnchanged(ctx, validHotels)
+	errs := hf.repository.StoreHotels(ctx, changed)
+	recorded := touched
+	for i, hotel := range changed {
+		if errs[i] != nil {
+			log.Printf("Failed to store hotel %s: %v", hotel.HotelID, errs[i])
+			continue
+		}
+		recorded = append(recorded, hotel)
+	}
+	// Unchanged hotels are recorded too, which moves their newest version's
+	// expiry along with the stored hotel's.
+	for _, hotel := range recorded {
+		if err := hf.recordVersion(ctx, hotel, runID); err != nil {
+			log.Printf("Failed to record version of hotel %s: %v", hotel.HotelID, err)
 		}
+	}
 
-		hotelsByDestination[hotel.DestinationID] = append(hotelsByDestination[hotel.DestinationID], hotel)
+	qualityReport := domain.NewQualityReport(qualityScores)
+	log.Printf("Quality distribution for %d hotels: min %.1f, median %.1f, mean %.1f, max %.1f, buckets %v",
+		qualityReport.Count, qualityReport.Min, qualityReport.Median, qualityReport.Mean, qualityReport.Max, qualityReport.Buckets)
+	if err := hf.repository.StoreQualityReport(ctx, qualityReport); err != nil {
+		log.Printf("Failed to store quality report: %v", err)
 	}
 
-	for destinationID, destinationHotels := range hotelsByDestination {
-		if err := hf.repository.StoreHotelsByDestinationID(destinationID, destinationHotels); err != nil {
-			log.Printf("Failed to store hotels for destination %d: %v", destinationID, err)
+	if complete {
+		destinations := domain.BuildDestinations(validHotels, hf.destinationRefs)
+		if err := hf.repository.StoreDestinations(ctx, destinations); err != nil {
+			log.Printf("Failed to store destinations: %v", err)
 		}
+	} else {
+		log.Printf("Kept the stored destinations: not every supplier was fetched")
 	}
 
-	return nil
+	return domain.BatchError(errs)
+}
+
+// refreshUnchanged extends the expiry of the hotels whose stored copy has the
+// same record fingerprint instead of rewriting them. It returns the hotels
+// that still need to be stored and the ones it refreshed.
+func (hf *HotelFetcher) refreshUnchanged(ctx context.Context, hotels []*domain.Hotel) (changed, touched []*domain.Hotel) {
+	hotelIDs := make([]string, len(hotels))
+	for i, hotel := range hotels {
+		hotelIDs[i] = hotel.HotelID
+	}
+	stored, err := hf.repository.GetHotelFingerprints(ctx, hotelIDs)
+	if err != nil {
+		log.Printf("Failed to load stored hotel fingerprints, rewriting all: %v", err)
+		return hotels, nil
+	}
+
+	var unchanged []*domain.Hotel
+	for _, hotel := range hotels {
+		if fingerprint, ok := stored[hotel.HotelID]; ok && fingerprint == hotel.RecordFingerprint() {
+			unchanged = append(unchanged, hotel)
+		} else {
+			changed = append(changed, hotel)
+		}
+	}
+
+	for i, err := range hf.repository.TouchHotels(ctx, unchanged) {
+		if err == nil {
+			touched = append(touched, unchanged[i])
+			continue
+		}
+		if !errors.Is(err, domain.ErrHotelNotStored) {
+			log.Printf("Failed to refresh hotel %s, rewriting it: %v", unchanged[i].HotelID, err)
+		}
+		changed = append(changed, unchanged[i])
+	}
+
+	log.Printf("Refreshed %d unchanged hotels", len(touched))
+	return changed, touched
 }
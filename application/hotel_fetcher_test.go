@@ -0,0 +1,46 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hotelsdatapipeline/infra"
+)
+
+func TestFetchAndProcessKeepsDestinationsOnPartialRun(t *testing.T) {
+	failing := false
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"hotel_id":"a","destination_id":1,"hotel_name":"Alpha"}]`))
+	}))
+	t.Cleanup(first.Close)
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[{"hotel_id":"b","destination_id":1,"hotel_name":"Beta"}]`))
+	}))
+	t.Cleanup(second.Close)
+
+	repository, err := infra.NewMemoryRepository("", infra.TTLPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewHotelFetcher(repository, []string{first.URL, second.URL})
+	ctx := context.Background()
+
+	for _, failing = range []bool{false, true} {
+		if err := fetcher.FetchAndProcess(ctx); err != nil {
+			t.Fatal(err)
+		}
+		destination, err := repository.GetDestinationByID(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if destination.HotelCount != 2 {
+			t.Errorf("hotel_count = %d after a run with failing supplier %v, want 2", destination.HotelCount, failing)
+		}
+	}
+}
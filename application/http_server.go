@@ -16,8 +16,8 @@ type HTTPServer struct {
 	router *httpinterface.Router
 }
 
-func NewHTTPServer(host string, port int, repository domain.HotelRepository) *HTTPServer {
-	router := httpinterface.NewRouter(repository)
+func NewHTTPServer(host string, port int, repository domain.Repository, locales domain.LocaleSettings, adminToken string) *HTTPServer {
+	router := httpinterface.NewRouter(repository, locales, adminToken)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", host, port),
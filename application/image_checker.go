@@ -0,0 +1,170 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+type ImageHealthChecker struct {
+	client      *http.Client
+	concurrency int
+	cacheTTL    time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]domain.ImageHealth
+}
+
+func NewImageHealthChecker(client *http.Client, concurrency int, cacheTTL time.Duration) *ImageHealthChecker {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &ImageHealthChecker{
+		client:      client,
+		concurrency: concurrency,
+		cacheTTL:    cacheTTL,
+		cache:       make(map[string]domain.ImageHealth),
+	}
+}
+
+// Check returns the health of every link, checking the ones without a fresh
+// cache entry. Once ctx is done, remaining links are not checked and nothing
+// further is cached, so callers should check ctx.Err() before using results.
+func (ic *ImageHealthChecker) Check(ctx context.Context, links []string) map[string]domain.ImageHealth {
+	results := make(map[string]domain.ImageHealth, len(links))
+	var pending []string
+
+	ic.mu.RLock()
+	for _, link := range links {
+		if _, done := results[link]; done {
+			continue
+		}
+		if cached, ok := ic.cache[link]; ok && time.Since(cached.CheckedAt) < ic.cacheTTL {
+			results[link] = cached
+			continue
+		}
+		results[link] = domain.ImageHealth{}
+		pending = append(pending, link)
+	}
+	ic.mu.RUnlock()
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i := 0; i < ic.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				health := ic.checkLink(ctx, link)
+				if ctx.Err() != nil {
+					continue
+				}
+
+				mu.Lock()
+				results[link] = health
+				mu.Unlock()
+
+				ic.mu.Lock()
+				ic.cache[link] = health
+				ic.mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, link := range pending {
+		select {
+		case jobs <- link:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func (ic *ImageHealthChecker) Cached() map[string]domain.ImageHealth {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	cached := make(map[string]domain.ImageHealth, len(ic.cache))
+	for link, health := range ic.cache {
+		cached[link] = health
+	}
+	return cached
+}
+
+func (ic *ImageHealthChecker) checkLink(ctx context.Context, link string) domain.ImageHealth {
+	health := domain.ImageHealth{CheckedAt: time.Now()}
+
+	resp, err := ic.request(ctx, http.MethodHead, link)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		resp, err = ic.request(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	defer resp.Body.Close()
+
+	health.StatusCode = resp.StatusCode
+	health.ContentType = resp.Header.Get("Content-Type")
+	health.Size = responseSize(resp)
+
+	isImage := health.ContentType == "" || strings.HasPrefix(health.ContentType, "image/")
+	health.Alive = resp.StatusCode >= 200 && resp.StatusCode < 300 && isImage
+	if !isImage {
+		health.Error = fmt.Sprintf("unexpected content type: %s", health.ContentType)
+	}
+
+	return health
+}
+
+// responseSize is the size of the image: the total from Content-Range for the
+// ranged GET fallback, otherwise Content-Length.
+func responseSize(resp *http.Response) int64 {
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		if i := strings.LastIndexByte(contentRange, '/'); i >= 0 {
+			if total, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil && total > 0 {
+				return total
+			}
+		}
+		return 0
+	}
+	if resp.ContentLength > 0 {
+		return resp.ContentLength
+	}
+	return 0
+}
+
+func (ic *ImageHealthChecker) request(ctx context.Context, method, link string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := ic.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	return resp, nil
+}
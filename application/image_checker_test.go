@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newImageHost(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/head.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", "2048")
+	})
+	mux.HandleFunc("/get-only.png", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("GET fallback sent Range %q", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Range", "bytes 0-0/4096")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte{0})
+	})
+	mux.HandleFunc("/moved.jpg", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/head.jpg", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/page.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	})
+	mux.HandleFunc("/missing.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestImageHealthCheckerCheck(t *testing.T) {
+	server := newImageHost(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		link       string
+		alive      bool
+		statusCode int
+		size       int64
+		hasError   bool
+	}{
+		{"head", server.URL + "/head.jpg", true, http.StatusOK, 2048, false},
+		{"get fallback", server.URL + "/get-only.png", true, http.StatusPartialContent, 4096, false},
+		{"redirect", server.URL + "/moved.jpg", true, http.StatusOK, 2048, false},
+		{"not found", server.URL + "/missing.jpg", false, http.StatusNotFound, 0, false},
+		{"not an image", server.URL + "/page.jpg", false, http.StatusOK, 0, true},
+		{"unreachable", closed.URL + "/head.jpg", false, 0, 0, true},
+	}
+
+	checker := NewImageHealthChecker(&http.Client{Timeout: 5 * time.Second}, 3, time.Hour)
+	links := make([]string, len(tests))
+	for i, tt := range tests {
+		links[i] = tt.link
+	}
+	results := checker.Check(context.Background(), links)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health, ok := results[tt.link]
+			if !ok {
+				t.Fatalf("no result for %s", tt.link)
+			}
+			if health.Alive != tt.alive || health.StatusCode != tt.statusCode || health.Size != tt.size {
+				t.Errorf("got alive=%v status=%d size=%d, want alive=%v status=%d size=%d",
+					health.Alive, health.StatusCode, health.Size, tt.alive, tt.statusCode, tt.size)
+			}
+			if (health.Error != "") != tt.hasError {
+				t.Errorf("got error %q, want error: %v", health.Error, tt.hasError)
+			}
+			if health.CheckedAt.IsZero() {
+				t.Error("CheckedAt not set")
+			}
+		})
+	}
+}
+
+func TestImageHealthCheckerCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "image/jpeg")
+	}))
+	defer server.Close()
+
+	checker := NewImageHealthChecker(nil, 1, time.Hour)
+	link := server.URL + "/a.jpg"
+	checker.Check(context.Background(), []string{link, link})
+	checker.Check(context.Background(), []string{link})
+
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1 with a cached result", requests)
+	}
+	if !checker.Cached()[link].Alive {
+		t.Errorf("cached health not alive: %+v", checker.Cached()[link])
+	}
+}
+
+func TestImageHealthCheckerCanceled(t *testing.T) {
+	server := newImageHost(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checker := NewImageHealthChecker(nil, 1, time.Hour)
+	checker.Check(ctx, []string{server.URL + "/head.jpg"})
+	if len(checker.Cached()) != 0 {
+		t.Errorf("canceled check cached %v", checker.Cached())
+	}
+}
@@ -0,0 +1,223 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// phoneExtension matches an extension trailing the number ("ext. 12", "x12").
+var phoneExtension = regexp.MustCompile(`(?i)[\s,;]*(?:ext\.?|extension|x|#)\s*\d+$`)
+
+var countryCallingCodes = map[string]string{
+	"sg": "65", "singapore": "65",
+	"my": "60", "malaysia": "60",
+	"id": "62", "indonesia": "62",
+	"th": "66", "thailand": "66",
+	"jp": "81", "japan": "81",
+	"cn": "86", "china": "86",
+	"hk": "852", "hong kong": "852",
+	"au": "61", "australia": "61",
+	"gb": "44", "uk": "44", "united kingdom": "44",
+	"fr": "33", "france": "33",
+	"de": "49", "germany": "49",
+	"it": "39", "italy": "39",
+	"es": "34", "spain": "34",
+	"us": "1", "usa": "1", "united states": "1",
+	"ca": "1", "canada": "1",
+}
+
+type ContactValue struct {
+	Value   string   `json:"value"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+type Contact struct {
+	Phones   []ContactValue `json:"phones,omitempty"`
+	Emails   []ContactValue `json:"emails,omitempty"`
+	Websites []ContactValue `json:"websites,omitempty"`
+}
+
+// UnmarshalJSON accepts both the stored form and supplier payloads that use
+// single strings or plain string lists (phone/phones, email/emails,
+// website/url/websites).
+func (c *Contact) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = Contact{
+		Phones:   decodeContactValues(raw["phone"], raw["phones"]),
+		Emails:   decodeContactValues(raw["email"], raw["emails"]),
+		Websites: decodeContactValues(raw["website"], raw["url"], raw["websites"]),
+	}
+	return nil
+}
+
+func decodeContactValues(fields ...json.RawMessage) []ContactValue {
+	var values []ContactValue
+	for _, field := range fields {
+		if len(field) == 0 {
+			continue
+		}
+
+		var single string
+		if err := json.Unmarshal(field, &single); err == nil {
+			values = append(values, ContactValue{Value: single})
+			continue
+		}
+
+		var list []string
+		if err := json.Unmarshal(field, &list); err == nil {
+			for _, value := range list {
+				values = append(values, ContactValue{Value: value})
+			}
+			continue
+		}
+
+		var stored []ContactValue
+		if err := json.Unmarshal(field, &stored); err == nil {
+			values = append(values, stored...)
+		}
+	}
+	return values
+}
+
+func (c Contact) clean(country string) Contact {
+	return Contact{
+		Phones:   normalizeContactValues(c.Phones, func(v string) string { return NormalizePhone(v, country) }),
+		Emails:   normalizeContactValues(c.Emails, NormalizeEmail),
+		Websites: normalizeContactValues(c.Websites, NormalizeWebsite),
+	}
+}
+
+func (c Contact) merge(other Contact) Contact {
+	return Contact{
+		Phones:   mergeContactValues(c.Phones, other.Phones),
+		Emails:   mergeContactValues(c.Emails, other.Emails),
+		Websites: mergeContactValues(c.Websites, other.Websites),
+	}
+}
+
+func (c *Contact) SetSource(source string) {
+	for _, values := range [][]ContactValue{c.Phones, c.Emails, c.Websites} {
+		for i := range values {
+			values[i].Sources = []string{source}
+		}
+	}
+}
+
+func normalizeContactValues(values []ContactValue, normalize func(string) string) []ContactValue {
+	var result []ContactValue
+	for _, value := range values {
+		if normalized := normalize(value.Value); normalized != "" {
+			result = append(result, ContactValue{Value: normalized, Sources: value.Sources})
+		}
+	}
+	return mergeContactValues(nil, result)
+}
+
+func mergeContactValues(a, b []ContactValue) []ContactValue {
+	index := make(map[string]int)
+	var result []ContactValue
+
+	for _, value := range append(append([]ContactValue{}, a...), b...) {
+		if i, seen := index[value.Value]; seen {
+			result[i].Sources = mergeSources(result[i].Sources, value.Sources)
+			continue
+		}
+		index[value.Value] = len(result)
+		result = append(result, ContactValue{Value: value.Value, Sources: mergeSources(nil, value.Sources)})
+	}
+
+	return result
+}
+
+func mergeSources(a, b []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, source := range append(append([]string{}, a...), b...) {
+		if source != "" && !seen[source] {
+			seen[source] = true
+			result = append(result, source)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// NormalizePhone returns the number in E.164 form (+6561234567). Labels such
+// as "Tel:" and a trailing extension are ignored. Numbers without an
+// international prefix get the calling code of country; anything that cannot
+// be resolved to 8-15 digits is dropped.
+func NormalizePhone(phone, country string) string {
+	phone = phoneExtension.ReplaceAllString(strings.TrimSpace(phone), "")
+	if i := strings.IndexAny(phone, "+0123456789"); i > 0 {
+		phone = phone[i:]
+	}
+
+	international := strings.HasPrefix(phone, "+")
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	default:
+		code, ok := countryCallingCodes[strings.ToLower(strings.TrimSpace(country))]
+		if !ok {
+			return ""
+		}
+		number = code + strings.TrimPrefix(number, "0")
+	}
+
+	if len(number) < 8 || len(number) > 15 {
+		return ""
+	}
+	return "+" + number
+}
+
+func NormalizeEmail(email string) string {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return ""
+	}
+
+	at := strings.LastIndex(address.Address, "@")
+	if at <= 0 || !strings.Contains(address.Address[at+1:], ".") {
+		return ""
+	}
+	return strings.ToLower(address.Address)
+}
+
+func NormalizeWebsite(website string) string {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return ""
+	}
+	if !strings.Contains(website, "://") {
+		website = "https://" + website
+	}
+
+	u, err := url.Parse(website)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(u.Host, ".") {
+		return ""
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	if u.Path == "/" {
+		u.Path = ""
+	}
+	return u.String()
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		phone   string
+		country string
+		want    string
+	}{
+		{"international", "+65 6123 4567", "", "+6561234567"},
+		{"label", "Tel: +65 6123 4567", "", "+6561234567"},
+		{"label with e", "Phone: +65 6123 4567", "", "+6561234567"},
+		{"national with country", "6123 4567", "Singapore", "+6561234567"},
+		{"trunk prefix", "020 7946 0958", "uk", "+442079460958"},
+		{"double zero prefix", "0065 6123 4567", "", "+6561234567"},
+		{"punctuation", "(+65) 6123-4567", "", "+6561234567"},
+		{"extension x", "+65 6123 4567 x12", "", "+6561234567"},
+		{"extension ext.", "+65 6123 4567 ext. 305", "", "+6561234567"},
+		{"extension word", "+1 212 555 0100, Extension 4", "", "+12125550100"},
+		{"label and extension", "Tel: +65 6123 4567 ext 9", "", "+6561234567"},
+		{"unknown country", "6123 4567", "atlantis", ""},
+		{"too short", "+65 123", "", ""},
+		{"too long", "+65 6123 4567 8901 2345", "", ""},
+		{"empty", "", "sg", ""},
+		{"no digits", "Tel: n/a", "sg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizePhone(tt.phone, tt.country); got != tt.want {
+				t.Errorf("NormalizePhone(%q, %q) = %q, want %q", tt.phone, tt.country, got, tt.want)
+			}
+		})
+	}
+}
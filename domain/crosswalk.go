@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CrosswalkKindHotel       = "hotel"
+	CrosswalkKindDestination = "destination"
+)
+
+type CrosswalkEntry struct {
+	Supplier    string `json:"supplier"`
+	Kind        string `json:"kind"`
+	NativeID    string `json:"native_id"`
+	CanonicalID string `json:"canonical_id"`
+}
+
+type UnmappedIDs struct {
+	HotelIDs       []string `json:"hotel_ids,omitempty"`
+	DestinationIDs []int    `json:"destination_ids,omitempty"`
+}
+
+type CrosswalkReport struct {
+	RunAt    time.Time              `json:"run_at"`
+	Unmapped map[string]UnmappedIDs `json:"unmapped"`
+}
+
+type CrosswalkRepository interface {
+	GetCrosswalkEntries(ctx context.Context) ([]CrosswalkEntry, error)
+	StoreCrosswalkEntry(ctx context.Context, entry CrosswalkEntry) error
+	DeleteCrosswalkEntry(ctx context.Context, supplier, kind, nativeID string) error
+	StoreCrosswalkReport(ctx context.Context, report CrosswalkReport) error
+	GetCrosswalkReport(ctx context.Context) (*CrosswalkReport, error)
+}
+
+// SupplierCrosswalk translates supplier-native hotel and destination IDs to
+// canonical ones. A supplier with at least one mapping of a kind is treated as
+// using native IDs for that kind, so its unmapped IDs are reported.
+type SupplierCrosswalk struct {
+	hotels       map[string]map[string]string
+	destinations map[string]map[int]int
+}
+
+func (e CrosswalkEntry) Normalize() (CrosswalkEntry, error) {
+	e.Supplier = strings.ToLower(strings.TrimSpace(e.Supplier))
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	e.NativeID = strings.TrimSpace(e.NativeID)
+	e.CanonicalID = strings.TrimSpace(e.CanonicalID)
+
+	if e.Supplier == "" || e.NativeID == "" || e.CanonicalID == "" {
+		return e, fmt.Errorf("supplier, native_id and canonical_id are required")
+	}
+
+	switch e.Kind {
+	case CrosswalkKindHotel:
+	case CrosswalkKindDestination:
+		if _, err := strconv.Atoi(e.NativeID); err != nil {
+			return e, fmt.Errorf("destination native_id must be an integer: %s", e.NativeID)
+		}
+		if id, err := strconv.Atoi(e.CanonicalID); err != nil || id <= 0 {
+			return e, fmt.Errorf("destination canonical_id must be a positive integer: %s", e.CanonicalID)
+		}
+	default:
+		return e, fmt.Errorf("kind must be %q or %q", CrosswalkKindHotel, CrosswalkKindDestination)
+	}
+
+	return e, nil
+}
+
+func NewSupplierCrosswalk(entries []CrosswalkEntry) *SupplierCrosswalk {
+	c := &SupplierCrosswalk{
+		hotels:       make(map[string]map[string]string),
+		destinations: make(map[string]map[int]int),
+	}
+
+	for _, entry := range entries {
+		entry, err := entry.Normalize()
+		if err != nil {
+			continue
+		}
+
+		switch entry.Kind {
+		case CrosswalkKindHotel:
+			if c.hotels[entry.Supplier] == nil {
+				c.hotels[entry.Supplier] = make(map[string]string)
+			}
+			c.hotels[entry.Supplier][entry.NativeID] = entry.CanonicalID
+		case CrosswalkKindDestination:
+			nativeID, _ := strconv.Atoi(entry.NativeID)
+			canonicalID, _ := strconv.Atoi(entry.CanonicalID)
+			if c.destinations[entry.Supplier] == nil {
+				c.destinations[entry.Supplier] = make(map[int]int)
+			}
+			c.destinations[entry.Supplier][nativeID] = canonicalID
+		}
+	}
+
+	return c
+}
+
+// Apply rewrites the IDs of hotels from supplier in place and returns the
+// native IDs that had no mapping.
+func (c *SupplierCrosswalk) Apply(supplier string, hotels []*Hotel) UnmappedIDs {
+	supplier = strings.ToLower(supplier)
+	hotelMap := c.hotels[supplier]
+	destinationMap := c.destinations[supplier]
+
+	var unmapped UnmappedIDs
+	seenHotels := make(map[string]bool)
+	seenDestinations := make(map[int]bool)
+
+	for _, hotel := range hotels {
+		if hotelMap != nil && hotel.HotelID != "" {
+			if canonicalID, ok := hotelMap[hotel.HotelID]; ok {
+				hotel.HotelID = canonicalID
+			} else if !seenHotels[hotel.HotelID] {
+				seenHotels[hotel.HotelID] = true
+				unmapped.HotelIDs = append(unmapped.HotelIDs, hotel.HotelID)
+			}
+		}
+
+		if destinationMap != nil {
+			if canonicalID, ok := destinationMap[hotel.DestinationID]; ok {
+				hotel.DestinationID = canonicalID
+			} else if !seenDestinations[hotel.DestinationID] {
+				seenDestinations[hotel.DestinationID] = true
+				unmapped.DestinationIDs = append(unmapped.DestinationIDs, hotel.DestinationID)
+			}
+		}
+	}
+
+	sort.Strings(unmapped.HotelIDs)
+	sort.Ints(unmapped.DestinationIDs)
+	return unmapped
+}
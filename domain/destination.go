@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+)
+
+type Coordinates struct {
+	Lat float64 `json:"lat" yaml:"lat"`
+	Lng float64 `json:"lng" yaml:"lng"`
+}
+
+type Destination struct {
+	DestinationID int            `json:"destination_id" yaml:"destination_id"`
+	Name          string         `json:"name" yaml:"name"`
+	Country       string         `json:"country" yaml:"country"`
+	Regions       []string       `json:"regions,omitempty" yaml:"regions"`
+	Centroid      *Coordinates   `json:"centroid,omitempty" yaml:"centroid"`
+	HotelCount    int            `json:"hotel_count" yaml:"-"`
+	AmenityFacets map[string]int `json:"amenity_facets,omitempty" yaml:"-"`
+}
+
+type DestinationRepository interface {
+	StoreDestinations(ctx context.Context, destinations []*Destination) error
+	GetDestinationByID(ctx context.Context, destinationID int) (*Destination, error)
+	GetAllDestinations(ctx context.Context) ([]*Destination, error)
+}
+
+// BuildDestinations derives one Destination per destination ID found in
+// hotels. Name, country, regions and centroid come from reference when it has
+// them; otherwise the most common hotel city and country and the mean of the
+// hotel coordinates are used.
+func BuildDestinations(hotels []*Hotel, reference map[int]*Destination) []*Destination {
+	hotelsByDestination := make(map[int][]*Hotel)
+	for _, hotel := range hotels {
+		hotelsByDestination[hotel.DestinationID] = append(hotelsByDestination[hotel.DestinationID], hotel)
+	}
+
+	destinations := make([]*Destination, 0, len(hotelsByDestination))
+	for destinationID, destinationHotels := range hotelsByDestination {
+		destination := &Destination{DestinationID: destinationID}
+		if ref, ok := reference[destinationID]; ok {
+			destination.Name = ref.Name
+			destination.Country = ref.Country
+			destination.Regions = ref.Regions
+			destination.Centroid = ref.Centroid
+		}
+
+		cities := make(map[string]int)
+		countries := make(map[string]int)
+		facets := make(map[string]int)
+		var latSum, lngSum float64
+		located := 0
+
+		for _, hotel := range destinationHotels {
+			if hotel.Location.City != "" {
+				cities[hotel.Location.City]++
+			}
+			if hotel.Location.Country != "" {
+				countries[hotel.Location.Country]++
+			}
+			if hotel.Location.HasCoordinates() {
+				latSum += hotel.Location.Lat
+				lngSum += hotel.Location.Lng
+				located++
+			}
+
+			seen := make(map[string]bool)
+			for _, amenity := range append(append([]string{}, hotel.Amenities.General...), hotel.Amenities.Room...) {
+				key := strings.ToLower(amenity)
+				if !seen[key] {
+					seen[key] = true
+					facets[key]++
+				}
+			}
+		}
+
+		if destination.Name == "" {
+			destination.Name = mostCommon(cities)
+		}
+		if destination.Country == "" {
+			destination.Country = mostCommon(countries)
+		}
+		if destination.Centroid == nil && located > 0 {
+			destination.Centroid = &Coordinates{
+				Lat: math.Round(latSum/float64(located)*1e6) / 1e6,
+				Lng: math.Round(lngSum/float64(located)*1e6) / 1e6,
+			}
+		}
+
+		destination.HotelCount = len(destinationHotels)
+		if len(facets) > 0 {
+			destination.AmenityFacets = facets
+		}
+
+		destinations = append(destinations, destination)
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].DestinationID < destinations[j].DestinationID
+	})
+
+	return destinations
+}
+
+func mostCommon(counts map[string]int) string {
+	best, bestCount := "", 0
+	for value, count := range counts {
+		if count > bestCount || (count == bestCount && value < best) {
+			best, bestCount = value, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildDestinations(t *testing.T) {
+	hotels := []*Hotel{
+		{HotelID: "a", DestinationID: 1, Location: Location{City: "Singapore", Country: "SG", Lat: 1.2, Lng: 103.8},
+			Amenities: Amenities{General: []string{"Pool", "WiFi"}, Room: []string{"wifi"}}},
+		{HotelID: "b", DestinationID: 1, Location: Location{City: "Singapore", Country: "SG", Lat: 1.4, Lng: 103.9},
+			Amenities: Amenities{General: []string{"pool"}}},
+		{HotelID: "c", DestinationID: 1, Location: Location{City: "Sentosa", Country: "SG"}},
+		{HotelID: "d", DestinationID: 2, Location: Location{City: "Kuta", Country: "ID", Lat: -8.7, Lng: 115.2}},
+	}
+	reference := map[int]*Destination{
+		2: {DestinationID: 2, Name: "Bali", Country: "Indonesia", Regions: []string{"Kuta"},
+			Centroid: &Coordinates{Lat: -8.4, Lng: 115.1}},
+	}
+
+	got, err := json.Marshal(BuildDestinations(hotels, reference))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[` +
+		`{"destination_id":1,"name":"Singapore","country":"SG","centroid":{"lat":1.3,"lng":103.85},"hotel_count":3,"amenity_facets":{"pool":2,"wifi":1}},` +
+		`{"destination_id":2,"name":"Bali","country":"Indonesia","regions":["Kuta"],"centroid":{"lat":-8.4,"lng":115.1},"hotel_count":1}` +
+		`]`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestBuildDestinationsNoHotels(t *testing.T) {
+	if destinations := BuildDestinations(nil, nil); len(destinations) != 0 {
+		t.Errorf("destinations = %+v, want none", destinations)
+	}
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+type HotelVersion struct {
+	Version     int       `json:"version"`
+	RunID       string    `json:"run_id"`
+	RecordedAt  time.Time `json:"recorded_at"`
+	Fingerprint string    `json:"fingerprint"`
+	Hotel       *Hotel    `json:"hotel"`
+	// Deleted marks a tombstone: the hotel was deleted at RecordedAt and
+	// Hotel is nil.
+	Deleted bool `json:"deleted,omitempty"`
+	// ExpiresAt is when the stored hotel expires unless it is stored again;
+	// nil never expires. It moves forward each time the same content is
+	// recorded again.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type FieldChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type HistoryRepository interface {
+	// AppendHotelVersion records version as the hotel's newest version as
+	// NextHotelVersion decides, setting its expiry from the store's hotel TTL.
+	// The version number is allocated atomically with the append, so
+	// overlapping runs never reuse one, and returned; 0 means nothing was
+	// appended.
+	AppendHotelVersion(ctx context.Context, version HotelVersion, maxVersions int) (int, error)
+	// GetHotelHistory returns retained versions, newest first.
+	GetHotelHistory(ctx context.Context, hotelID string) ([]HotelVersion, error)
+	// GetDestinationHistoryHotelIDs returns the hotels with a version
+	// recorded in the destination. They may have moved or been deleted since.
+	GetDestinationHistoryHotelIDs(ctx context.Context, destinationID int) ([]string, error)
+}
+
+func NewRunID(t time.Time) string {
+	return t.UTC().Format("20060102T150405.000Z")
+}
+
+// Fingerprint identifies the hotel's content for change detection. Image
+// health is left out so periodic link checks alone do not create versions.
+func (h *Hotel) Fingerprint() string {
+	copied := *h
+	copied.Images = make(Images, len(h.Images))
+	for category, images := range h.Images {
+		stripped := make([]Image, len(images))
+		for i, img := range images {
+			img.Health = nil
+			stripped[i] = img
+		}
+		copied.Images[category] = stripped
+	}
+	if copied.PrimaryImage != nil {
+		primary := *copied.PrimaryImage
+		primary.Health = nil
+		copied.PrimaryImage = &primary
+	}
+
+	data, _ := json.Marshal(&copied)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordFingerprint identifies everything stored for the hotel, image health
+// included, so a hotel whose stored copy has the same one can be refreshed
+// instead of rewritten.
+func (h *Hotel) RecordFingerprint() string {
+	data, _ := json.Marshal(h)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func FindHotelVersion(history []HotelVersion, version int) (*HotelVersion, error) {
+	for i := range history {
+		if history[i].Version == version {
+			return &history[i], nil
+		}
+	}
+	return nil, fmt.Errorf("version %d not retained", version)
+}
+
+// Live reports whether the version's hotel was stored at t: it is not a
+// tombstone and had not expired.
+func (v *HotelVersion) Live(t time.Time) bool {
+	return !v.Deleted && (v.ExpiresAt == nil || t.Before(*v.ExpiresAt))
+}
+
+// NextHotelVersion decides how version is recorded at now on top of latest,
+// the hotel's newest version or nil. It returns the number to append version
+// under, or 0 when latest already stands for it: a live version with the same
+// fingerprint, or any version that is no longer live for a tombstone. refresh
+// reports whether latest then only needs version's expiry.
+func NextHotelVersion(latest *HotelVersion, version HotelVersion, now time.Time) (number int, refresh bool) {
+	switch {
+	case latest == nil:
+		if version.Deleted {
+			return 0, false
+		}
+		return 1, false
+	case !latest.Live(now):
+		if version.Deleted {
+			return 0, false
+		}
+	case !version.Deleted && latest.Fingerprint == version.Fingerprint:
+		return 0, latest.ExpiresAt != nil || version.ExpiresAt != nil
+	}
+	return latest.Version + 1, false
+}
+
+// NewTombstone returns the version recording that a hotel was deleted at t.
+func NewTombstone(t time.Time) HotelVersion {
+	return HotelVersion{RecordedAt: t, Deleted: true}
+}
+
+// HotelAsOf returns the version that was current at asOf, or false when the
+// hotel had no retained version by then, had been deleted or had expired.
+func HotelAsOf(history []HotelVersion, asOf time.Time) (*Hotel, bool) {
+	for _, version := range history {
+		if !version.RecordedAt.After(asOf) {
+			if !version.Live(asOf) {
+				return nil, false
+			}
+			return version.Hotel, true
+		}
+	}
+	return nil, false
+}
+
+// DiffHotels compares the JSON form of two hotels and returns one change per
+// differing leaf field. Arrays are compared as whole values.
+func DiffHotels(from, to *Hotel) ([]FieldChange, error) {
+	fromMap, err := toJSONMap(from)
+	if err != nil {
+		return nil, err
+	}
+	toMap, err := toJSONMap(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	diffValues("", fromMap, toMap, &changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func toJSONMap(hotel *Hotel) (map[string]interface{}, error) {
+	data, err := json.Marshal(hotel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal hotel: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal hotel: %w", err)
+	}
+	return result, nil
+}
+
+func diffValues(path string, from, to interface{}, changes *[]FieldChange) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+
+	if fromIsMap && toIsMap {
+		keys := make(map[string]bool)
+		for key := range fromMap {
+			keys[key] = true
+		}
+		for key := range toMap {
+			keys[key] = true
+		}
+		for key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			diffValues(childPath, fromMap[key], toMap[key], changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, FieldChange{Path: path, From: from, To: to})
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHotelAsOf(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return base.Add(time.Duration(hours) * time.Hour) }
+	expiresAt := func(hours int) *time.Time {
+		t := at(hours)
+		return &t
+	}
+	version := func(number, recordedAt int, name string) HotelVersion {
+		return HotelVersion{Version: number, RecordedAt: at(recordedAt), Hotel: &Hotel{HotelID: "h1", HotelName: name}}
+	}
+	tombstone := func(number, recordedAt int) HotelVersion {
+		v := NewTombstone(at(recordedAt))
+		v.Version = number
+		return v
+	}
+	expiring := func(v HotelVersion, hours int) HotelVersion {
+		v.ExpiresAt = expiresAt(hours)
+		return v
+	}
+
+	tests := []struct {
+		name     string
+		history  []HotelVersion
+		asOf     int
+		wantName string
+		wantOK   bool
+	}{
+		{"no history", nil, 5, "", false},
+		{"before the first version", []HotelVersion{version(1, 2, "a")}, 1, "", false},
+		{"at the first version", []HotelVersion{version(1, 2, "a")}, 2, "a", true},
+		{"between versions", []HotelVersion{version(2, 4, "b"), version(1, 2, "a")}, 3, "a", true},
+		{"after the newest version", []HotelVersion{version(2, 4, "b"), version(1, 2, "a")}, 9, "b", true},
+		{"after a tombstone", []HotelVersion{tombstone(2, 4), version(1, 2, "a")}, 5, "", false},
+		{"before a tombstone", []HotelVersion{tombstone(2, 4), version(1, 2, "a")}, 3, "a", true},
+		{"added again after a tombstone", []HotelVersion{version(3, 6, "c"), tombstone(2, 4), version(1, 2, "a")}, 7, "c", true},
+		{"before expiry", []HotelVersion{expiring(version(1, 2, "a"), 5)}, 4, "a", true},
+		{"at expiry", []HotelVersion{expiring(version(1, 2, "a"), 5)}, 5, "", false},
+		{"expired before the next version", []HotelVersion{version(2, 8, "b"), expiring(version(1, 2, "a"), 5)}, 6, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotel, ok := HotelAsOf(tt.history, at(tt.asOf))
+			if ok != tt.wantOK {
+				t.Fatalf("HotelAsOf ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && hotel.HotelName != tt.wantName {
+				t.Errorf("HotelAsOf returned %q, want %q", hotel.HotelName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestNextHotelVersion(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	live := &HotelVersion{Version: 3, Fingerprint: "a"}
+	expiring := &HotelVersion{Version: 3, Fingerprint: "a", ExpiresAt: &future}
+	expired := &HotelVersion{Version: 3, Fingerprint: "a", ExpiresAt: &past}
+	deleted := &HotelVersion{Version: 3, Deleted: true}
+
+	same := HotelVersion{Fingerprint: "a"}
+	sameExpiring := HotelVersion{Fingerprint: "a", ExpiresAt: &future}
+	other := HotelVersion{Fingerprint: "b"}
+	tombstone := NewTombstone(now)
+
+	tests := []struct {
+		name        string
+		latest      *HotelVersion
+		version     HotelVersion
+		wantNumber  int
+		wantRefresh bool
+	}{
+		{"first version", nil, same, 1, false},
+		{"tombstone without history", nil, tombstone, 0, false},
+		{"same content", live, same, 0, false},
+		{"same content refreshes expiry", expiring, sameExpiring, 0, true},
+		{"new content", live, other, 4, false},
+		{"same content after expiry", expired, same, 4, false},
+		{"same content after deletion", deleted, same, 4, false},
+		{"tombstone of a live hotel", live, tombstone, 4, false},
+		{"tombstone of an expired hotel", expired, tombstone, 0, false},
+		{"tombstone of a deleted hotel", deleted, tombstone, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, refresh := NextHotelVersion(tt.latest, tt.version, now)
+			if number != tt.wantNumber || refresh != tt.wantRefresh {
+				t.Errorf("NextHotelVersion = (%d, %v), want (%d, %v)", number, refresh, tt.wantNumber, tt.wantRefresh)
+			}
+		})
+	}
+}
+
+func TestRecordFingerprintCoversImageHealth(t *testing.T) {
+	hotel := &Hotel{HotelID: "h1", Images: Images{"rooms": {{Link: "https://example.com/1.jpg"}}}}
+	checked := &Hotel{HotelID: "h1", Images: Images{"rooms": {{Link: "https://example.com/1.jpg",
+		Health: &ImageHealth{Alive: false, StatusCode: 404}}}}}
+
+	if hotel.Fingerprint() != checked.Fingerprint() {
+		t.Error("image health changed the history fingerprint")
+	}
+	if hotel.RecordFingerprint() == checked.RecordFingerprint() {
+		t.Error("image health left the record fingerprint unchanged")
+	}
+}
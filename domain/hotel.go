@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -14,12 +16,29 @@ type Hotel struct {
 	Details           string    `json:"details"`
 	Amenities         Amenities `json:"amenities"`
 	Images            Images    `json:"images"`
+	PrimaryImage      *Image    `json:"primary_image,omitempty"`
 	BookingConditions []string  `json:"booking_conditions"`
+	Rating            Rating    `json:"rating"`
+	Policies          Policies  `json:"policies"`
+	Contact           Contact   `json:"contact"`
+	Quality           *Quality  `json:"quality,omitempty"`
+	Sources           []string  `json:"sources,omitempty"`
+
+	// Language is the locale of the base text fields when a supplier only
+	// delivered them in a language other than BaseLocale.
+	Language     string                      `json:"language,omitempty"`
+	Translations map[string]LocalizedContent `json:"translations,omitempty"`
+	Locale       string                      `json:"locale,omitempty"`
+
+	agreements []float64
 }
 
 type Location struct {
-	Address string `json:"address"`
-	Country string `json:"country"`
+	Address string  `json:"address"`
+	City    string  `json:"city,omitempty"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat,omitempty"`
+	Lng     float64 `json:"lng,omitempty"`
 }
 
 type Amenities struct {
@@ -27,82 +46,138 @@ type Amenities struct {
 	Room    []string `json:"room"`
 }
 
-type Image struct {
-	Link    string `json:"link"`
-	Caption string `json:"caption"`
+type Repository interface {
+	HotelRepository
+	MatchRepository
+	CrosswalkRepository
+	DestinationRepository
+	QualityRepository
+	HistoryRepository
 }
 
-type Images struct {
-	Rooms []Image `json:"rooms"`
-	Site  []Image `json:"site"`
+type HotelRepository interface {
+	// StoreHotels writes hotels in batches, moving each one to its
+	// destination. It returns one error per hotel, in order, nil for the
+	// hotels that were stored.
+	StoreHotels(ctx context.Context, hotels []*Hotel) []error
+	// TouchHotels extends the expiry of stored hotels, and of their place in
+	// their destination, without rewriting them. It returns one error per
+	// hotel, ErrHotelNotStored for the hotels that are not stored.
+	TouchHotels(ctx context.Context, hotels []*Hotel) []error
+	// DeleteHotelsExcept deletes every stored hotel whose ID is not in
+	// hotelIDs and returns how many were deleted.
+	DeleteHotelsExcept(ctx context.Context, hotelIDs []string) (int, error)
+	GetHotelByID(ctx context.Context, hotelID string) (*Hotel, error)
+	// GetHotelsByDestinationID returns one page of the destination's hotels
+	// ordered by hotel ID, and the total number of hotels in the destination.
+	GetHotelsByDestinationID(ctx context.Context, destinationID int, page Page) ([]*Hotel, int, error)
+	GetHotelsByIDRange(ctx context.Context, hotelIDs []string) ([]*Hotel, error)
+	GetAllHotels(ctx context.Context) ([]*Hotel, error)
+	// GetHotelFingerprints returns the RecordFingerprint of each listed hotel
+	// that is stored, keyed by hotel ID. Hotels stored before fingerprints
+	// were kept are left out.
+	GetHotelFingerprints(ctx context.Context, hotelIDs []string) (map[string]string, error)
 }
 
-type HotelRepository interface {
-	StoreHotelByID(hotelID string, hotel *Hotel) error
-	StoreHotelsByDestinationID(destinationID int, hotels []*Hotel) error
-	GetHotelByID(hotelID string) (*Hotel, error)
-	GetHotelsByDestinationID(destinationID int) ([]*Hotel, error)
-	GetHotelsByIDRange(hotelIDs []string) ([]*Hotel, error)
+var ErrHotelNotStored = errors.New("hotel not stored")
+
+// BatchError summarizes the per-hotel errors of StoreHotels. It is nil when
+// every hotel was stored.
+func BatchError(errs []error) error {
+	var first error
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d hotels failed: %w", failed, len(errs), first)
 }
 
 func (h *Hotel) CleanData() {
 	h.HotelID = strings.TrimSpace(h.HotelID)
-	h.HotelName = strings.TrimSpace(h.HotelName)
-	h.Location.Address = strings.TrimSpace(h.Location.Address)
-	h.Location.Country = strings.TrimSpace(h.Location.Country)
-	h.Details = strings.TrimSpace(h.Details)
+	h.HotelName = SanitizeText(h.HotelName)
+	h.Location.Address = SanitizeText(h.Location.Address)
+	h.Location.City = SanitizeText(h.Location.City)
+	h.Location.Country = SanitizeText(h.Location.Country)
+	h.Details = SanitizeText(h.Details)
 
 	h.Amenities.General = cleanStringSlice(h.Amenities.General)
 	h.Amenities.Room = cleanStringSlice(h.Amenities.Room)
 
 	h.BookingConditions = cleanStringSlice(h.BookingConditions)
+	h.Policies = ExtractPolicies(h.BookingConditions)
+
+	h.Rating = h.Rating.clean()
+	h.Contact = h.Contact.clean(h.Location.Country)
+
+	h.Images = h.Images.clean()
+	h.PrimaryImage = h.Images.Primary()
+
+	h.Translations = cleanTranslations(h.Translations)
+	h.moveToTranslation()
+}
 
-	h.Images.Rooms = cleanImages(h.Images.Rooms)
-	h.Images.Site = cleanImages(h.Images.Site)
+func (h *Hotel) SetSource(source string) {
+	h.Sources = []string{source}
+	h.Contact.SetSource(source)
 }
 
 func (h *Hotel) MergeWith(other *Hotel) {
-	if strings.TrimSpace(h.HotelName) == "" && strings.TrimSpace(other.HotelName) != "" {
-		h.HotelName = other.HotelName
-	}
+	h.recordAgreement(other)
+	h.Sources = mergeSources(h.Sources, other.Sources)
+
+	h.mergeBaseText(other)
 	if strings.TrimSpace(h.Location.Address) == "" && strings.TrimSpace(other.Location.Address) != "" {
 		h.Location.Address = other.Location.Address
 	}
+	if strings.TrimSpace(h.Location.City) == "" && strings.TrimSpace(other.Location.City) != "" {
+		h.Location.City = other.Location.City
+	}
 	if strings.TrimSpace(h.Location.Country) == "" && strings.TrimSpace(other.Location.Country) != "" {
 		h.Location.Country = other.Location.Country
 	}
 
-	if len(strings.TrimSpace(other.Details)) > len(strings.TrimSpace(h.Details)) {
-		h.Details = other.Details
+	if !h.Location.HasCoordinates() && other.Location.HasCoordinates() {
+		h.Location.Lat = other.Location.Lat
+		h.Location.Lng = other.Location.Lng
 	}
 
-	h.Amenities.General = mergeStringSlices(h.Amenities.General, other.Amenities.General)
-	h.Amenities.Room = mergeStringSlices(h.Amenities.Room, other.Amenities.Room)
-
 	h.BookingConditions = mergeStringSlices(h.BookingConditions, other.BookingConditions)
+	h.Policies = ExtractPolicies(h.BookingConditions)
+
+	h.Rating = h.Rating.merge(other.Rating)
+	h.Contact = h.Contact.merge(other.Contact)
+
+	h.Images = h.Images.merge(other.Images)
+	h.PrimaryImage = h.Images.Primary()
+
+	h.Translations = mergeTranslations(h.Translations, other.Translations)
+}
+
+func (l Location) HasCoordinates() bool {
+	return l.Lat != 0 || l.Lng != 0
+}
 
-	h.Images.Rooms = mergeImages(h.Images.Rooms, other.Images.Rooms)
-	h.Images.Site = mergeImages(h.Images.Site, other.Images.Site)
+func (h *Hotel) WithoutDeadImages() *Hotel {
+	copied := *h
+	copied.Images = h.Images.WithoutDead()
+	copied.PrimaryImage = copied.Images.Primary()
+	return &copied
 }
 
 func cleanStringSlice(slice []string) []string {
 	var result []string
 	for _, s := range slice {
-		trimmed := strings.TrimSpace(s)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
-}
-
-func cleanImages(images []Image) []Image {
-	var result []Image
-	for _, img := range images {
-		img.Link = strings.TrimSpace(img.Link)
-		img.Caption = strings.TrimSpace(img.Caption)
-		if img.Link != "" {
-			result = append(result, img)
+		cleaned := SanitizeText(s)
+		if cleaned != "" {
+			result = append(result, cleaned)
 		}
 	}
 	return result
@@ -113,10 +188,11 @@ func mergeStringSlices(a, b []string) []string {
 	var result []string
 
 	for _, s := range append(a, b...) {
-		lower := strings.ToLower(strings.TrimSpace(s))
-		if !seen[lower] && lower != "" {
-			seen[lower] = true
-			result = append(result, strings.TrimSpace(s))
+		cleaned := SanitizeText(s)
+		key := strings.ToLower(cleaned)
+		if !seen[key] && key != "" {
+			seen[key] = true
+			result = append(result, cleaned)
 		}
 	}
 
@@ -124,24 +200,6 @@ func mergeStringSlices(a, b []string) []string {
 	return result
 }
 
-func mergeImages(a, b []Image) []Image {
-	seen := make(map[string]bool)
-	var result []Image
-
-	for _, img := range append(a, b...) {
-		link := strings.TrimSpace(img.Link)
-		if !seen[link] && link != "" {
-			seen[link] = true
-			result = append(result, Image{
-				Link:    link,
-				Caption: strings.TrimSpace(img.Caption),
-			})
-		}
-	}
-
-	return result
-}
-
 func (h *Hotel) Validate() error {
 	if h.HotelID == "" {
 		return fmt.Errorf("hotel ID is required")
@@ -149,5 +207,8 @@ func (h *Hotel) Validate() error {
 	if h.DestinationID <= 0 {
 		return fmt.Errorf("destination ID must be positive")
 	}
+	if err := h.Rating.Validate(); err != nil {
+		return fmt.Errorf("invalid rating: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,299 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ImageCategoryRooms     = "rooms"
+	ImageCategorySite      = "site"
+	ImageCategoryAmenities = "amenities"
+)
+
+var imageCategoryAliases = map[string]string{
+	"room":       ImageCategoryRooms,
+	"rooms":      ImageCategoryRooms,
+	"bedroom":    ImageCategoryRooms,
+	"bedrooms":   ImageCategoryRooms,
+	"site":       ImageCategorySite,
+	"sites":      ImageCategorySite,
+	"exterior":   ImageCategorySite,
+	"hotel":      ImageCategorySite,
+	"property":   ImageCategorySite,
+	"amenity":    ImageCategoryAmenities,
+	"amenities":  ImageCategoryAmenities,
+	"facility":   ImageCategoryAmenities,
+	"facilities": ImageCategoryAmenities,
+}
+
+// legacyImageCategories were fixed fields of the API's images object before
+// categories were opened up, so they are always present in its JSON.
+var legacyImageCategories = []string{ImageCategoryRooms, ImageCategorySite}
+
+var primaryImageCategoryOrder = []string{ImageCategorySite, ImageCategoryRooms, ImageCategoryAmenities}
+
+var imageSizingParams = map[string]bool{
+	"w": true, "h": true, "width": true, "height": true, "size": true,
+	"fit": true, "crop": true, "q": true, "quality": true, "dpr": true,
+	"resize": true, "auto": true, "fm": true, "format": true,
+}
+
+type Image struct {
+	Link    string       `json:"link"`
+	Caption string       `json:"caption"`
+	Health  *ImageHealth `json:"health,omitempty"`
+}
+
+type ImageHealth struct {
+	Alive       bool      `json:"alive"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// Images holds pictures keyed by normalized category name.
+type Images map[string][]Image
+
+func (img *Image) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Link        string       `json:"link"`
+		URL         string       `json:"url"`
+		Caption     string       `json:"caption"`
+		Description string       `json:"description"`
+		Health      *ImageHealth `json:"health"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	img.Link = raw.Link
+	if img.Link == "" {
+		img.Link = raw.URL
+	}
+	img.Caption = raw.Caption
+	if img.Caption == "" {
+		img.Caption = raw.Description
+	}
+	img.Health = raw.Health
+
+	return nil
+}
+
+func (imgs *Images) UnmarshalJSON(data []byte) error {
+	var raw map[string][]Image
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	result := make(Images, len(raw))
+	for category, images := range raw {
+		normalized := NormalizeImageCategory(category)
+		if normalized == "" {
+			continue
+		}
+		result[normalized] = append(result[normalized], images...)
+	}
+
+	*imgs = result
+	return nil
+}
+
+func (imgs Images) MarshalJSON() ([]byte, error) {
+	result := make(map[string][]Image, len(imgs)+len(legacyImageCategories))
+	for _, category := range legacyImageCategories {
+		result[category] = []Image{}
+	}
+	for category, images := range imgs {
+		if images != nil {
+			result[category] = images
+		}
+	}
+	return json.Marshal(result)
+}
+
+func NormalizeImageCategory(category string) string {
+	key := strings.ToLower(strings.TrimSpace(category))
+	key = strings.ReplaceAll(key, " ", "_")
+	key = strings.ReplaceAll(key, "-", "_")
+	if alias, ok := imageCategoryAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+func (imgs Images) Categories() []string {
+	categories := make([]string, 0, len(imgs))
+	for category := range imgs {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// Primary picks the cover image: the first captioned picture from the most
+// representative category (site, then rooms, then amenities, then the rest
+// alphabetically), falling back to the first uncaptioned one.
+func (imgs Images) Primary() *Image {
+	order := append([]string{}, primaryImageCategoryOrder...)
+	for _, category := range imgs.Categories() {
+		if !containsString(primaryImageCategoryOrder, category) {
+			order = append(order, category)
+		}
+	}
+
+	var fallback *Image
+	for _, category := range order {
+		for i := range imgs[category] {
+			img := imgs[category][i]
+			if img.IsDead() {
+				continue
+			}
+			if img.Caption != "" {
+				return &img
+			}
+			if fallback == nil {
+				fallback = &img
+			}
+		}
+	}
+
+	return fallback
+}
+
+func (img Image) IsDead() bool {
+	return img.Health != nil && !img.Health.Alive
+}
+
+func (imgs Images) WithoutDead() Images {
+	result := make(Images, len(imgs))
+	for category, images := range imgs {
+		var alive []Image
+		for _, img := range images {
+			if !img.IsDead() {
+				alive = append(alive, img)
+			}
+		}
+		if len(alive) > 0 {
+			result[category] = alive
+		}
+	}
+	return result
+}
+
+func (imgs Images) Links() []string {
+	var links []string
+	for _, images := range imgs {
+		for _, img := range images {
+			links = append(links, img.Link)
+		}
+	}
+	return links
+}
+
+func (imgs Images) ApplyHealth(health map[string]ImageHealth) {
+	for _, images := range imgs {
+		for i := range images {
+			if status, ok := health[images[i].Link]; ok {
+				status := status
+				images[i].Health = &status
+			}
+		}
+	}
+}
+
+func (imgs Images) clean() Images {
+	result := make(Images, len(imgs))
+	for category, images := range imgs {
+		cleaned := mergeImages(nil, images)
+		if len(cleaned) > 0 {
+			result[category] = cleaned
+		}
+	}
+	return result
+}
+
+func (imgs Images) merge(other Images) Images {
+	result := make(Images, len(imgs)+len(other))
+	for category, images := range imgs {
+		result[category] = images
+	}
+	for category, images := range other {
+		result[category] = mergeImages(result[category], images)
+	}
+	return result
+}
+
+func mergeImages(a, b []Image) []Image {
+	index := make(map[string]int)
+	var result []Image
+
+	for _, img := range append(a, b...) {
+		link := strings.TrimSpace(img.Link)
+		if link == "" {
+			continue
+		}
+		caption := SanitizeText(img.Caption)
+		key := canonicalImageKey(link)
+
+		if i, seen := index[key]; seen {
+			existing := &result[i]
+			if len(caption) > len(existing.Caption) {
+				existing.Caption = caption
+			}
+			if strings.HasPrefix(link, "https://") && !strings.HasPrefix(existing.Link, "https://") {
+				existing.Link = link
+				existing.Health = img.Health
+			}
+			if existing.Health == nil {
+				existing.Health = img.Health
+			}
+			continue
+		}
+
+		index[key] = len(result)
+		result = append(result, Image{
+			Link:    link,
+			Caption: caption,
+			Health:  img.Health,
+		})
+	}
+
+	return result
+}
+
+// canonicalImageKey identifies the same picture across URL variants: scheme,
+// host case, trailing slashes and resizing query parameters are ignored.
+func canonicalImageKey(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(strings.ToLower(link), "/")
+	}
+
+	query := u.Query()
+	for param := range query {
+		if imageSizingParams[strings.ToLower(param)] {
+			query.Del(param)
+		}
+	}
+
+	key := strings.ToLower(u.Host) + strings.TrimRight(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		key += "?" + encoded
+	}
+	return key
+}
+
+func containsString(slice []string, value string) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
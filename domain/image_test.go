@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestImagesJSONKeepsLegacyCategories(t *testing.T) {
+	tests := []struct {
+		name   string
+		images Images
+		want   string
+	}{
+		{"nil", nil, `{"rooms":[],"site":[]}`},
+		{"amenities only", Images{ImageCategoryAmenities: {{Link: "a"}}},
+			`{"amenities":[{"link":"a","caption":""}],"rooms":[],"site":[]}`},
+		{"rooms", Images{ImageCategoryRooms: {{Link: "r", Caption: "Bed"}}},
+			`{"rooms":[{"link":"r","caption":"Bed"}],"site":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.images)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+
+			var decoded Images
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if len(decoded[ImageCategoryRooms]) != len(tt.images[ImageCategoryRooms]) {
+				t.Errorf("rooms did not round-trip: %v", decoded)
+			}
+		})
+	}
+}
+
+func TestHotelJSONHasLegacyImageKeys(t *testing.T) {
+	data, err := json.Marshal(&Hotel{HotelID: "h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Images map[string]json.RawMessage `json:"images"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, category := range []string{"rooms", "site"} {
+		if string(raw.Images[category]) != "[]" {
+			t.Errorf("images.%s = %s, want []", category, raw.Images[category])
+		}
+	}
+}
+
+func TestCanonicalImageKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"http://cdn.example.com/1.jpg", "https://cdn.example.com/1.jpg", true},
+		{"https://CDN.example.com/1.jpg/", "https://cdn.example.com/1.jpg", true},
+		{"https://cdn.example.com/1.jpg?w=200&h=100", "https://cdn.example.com/1.jpg?width=800", true},
+		{"https://cdn.example.com/1.jpg?id=1&q=80", "https://cdn.example.com/1.jpg?id=1", true},
+		{"https://cdn.example.com/1.jpg?id=1", "https://cdn.example.com/1.jpg?id=2", false},
+		{"https://cdn.example.com/1.jpg", "https://cdn.example.com/2.jpg", false},
+		{"https://cdn.example.com/Room.jpg", "https://cdn.example.com/room.jpg", false},
+	}
+
+	for _, tt := range tests {
+		if same := canonicalImageKey(tt.a) == canonicalImageKey(tt.b); same != tt.same {
+			t.Errorf("%s and %s: same = %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
+
+func TestMergeImages(t *testing.T) {
+	dead := &ImageHealth{Alive: false, StatusCode: 404}
+	tests := []struct {
+		name string
+		a, b []Image
+		want []Image
+	}{
+		{"size variants",
+			[]Image{{Link: "https://cdn.example.com/1.jpg?w=200", Caption: "Pool"}},
+			[]Image{{Link: "https://cdn.example.com/1.jpg?w=1200", Caption: "Outdoor pool"}},
+			[]Image{{Link: "https://cdn.example.com/1.jpg?w=200", Caption: "Outdoor pool"}}},
+		{"https preferred",
+			[]Image{{Link: "http://cdn.example.com/1.jpg", Caption: "Lobby", Health: dead}},
+			[]Image{{Link: "https://cdn.example.com/1.jpg"}},
+			[]Image{{Link: "https://cdn.example.com/1.jpg", Caption: "Lobby"}}},
+		{"different pictures",
+			[]Image{{Link: "https://cdn.example.com/1.jpg"}},
+			[]Image{{Link: "https://cdn.example.com/2.jpg"}},
+			[]Image{{Link: "https://cdn.example.com/1.jpg"}, {Link: "https://cdn.example.com/2.jpg"}}},
+		{"blank links and captions cleaned",
+			[]Image{{Link: "  "}, {Link: " https://cdn.example.com/1.jpg ", Caption: "<b>Bar</b>"}},
+			nil,
+			[]Image{{Link: "https://cdn.example.com/1.jpg", Caption: "Bar"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(mergeImages(tt.a, tt.b))
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestImagesMergeCategoryAliases(t *testing.T) {
+	var images Images
+	if err := json.Unmarshal([]byte(`{
+		"room": [{"link": "https://cdn.example.com/1.jpg"}],
+		"Bedrooms": [{"url": "https://cdn.example.com/2.jpg", "description": "Suite"}],
+		"exterior": [{"link": "https://cdn.example.com/3.jpg"}]
+	}`), &images); err != nil {
+		t.Fatal(err)
+	}
+	merged := images.clean().merge(Images{"rooms": {{Link: "http://cdn.example.com/1.jpg?w=100", Caption: "Twin room"}}})
+
+	if got := merged.Categories(); len(got) != 2 || got[0] != ImageCategoryRooms || got[1] != ImageCategorySite {
+		t.Fatalf("categories = %v, want [rooms site]", got)
+	}
+	// Aliases are merged in map order, so rooms are compared by link.
+	captions := make(map[string]string)
+	for _, img := range merged[ImageCategoryRooms] {
+		captions[img.Link] = img.Caption
+	}
+	if len(captions) != 2 || captions["https://cdn.example.com/1.jpg"] != "Twin room" || captions["https://cdn.example.com/2.jpg"] != "Suite" {
+		t.Errorf("rooms = %+v", merged[ImageCategoryRooms])
+	}
+}
+
+func TestImagesPrimary(t *testing.T) {
+	dead := &ImageHealth{Alive: false}
+	tests := []struct {
+		name   string
+		images Images
+		want   string
+	}{
+		{"none", nil, ""},
+		{"site before rooms", Images{
+			ImageCategoryRooms: {{Link: "room", Caption: "Room"}},
+			ImageCategorySite:  {{Link: "site", Caption: "Front"}},
+		}, "site"},
+		{"captioned before uncaptioned", Images{
+			ImageCategorySite:  {{Link: "site"}},
+			ImageCategoryRooms: {{Link: "room", Caption: "Room"}},
+		}, "room"},
+		{"uncaptioned fallback in category order", Images{
+			"spa":              {{Link: "spa"}},
+			ImageCategoryRooms: {{Link: "room"}},
+		}, "room"},
+		{"other categories alphabetically", Images{
+			"spa": {{Link: "spa", Caption: "Spa"}},
+			"bar": {{Link: "bar", Caption: "Bar"}},
+		}, "bar"},
+		{"dead images skipped", Images{
+			ImageCategorySite:  {{Link: "site", Caption: "Front", Health: dead}},
+			ImageCategoryRooms: {{Link: "room"}},
+		}, "room"},
+		{"only dead images", Images{ImageCategorySite: {{Link: "site", Health: dead}}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if primary := tt.images.Primary(); primary != nil {
+				got = primary.Link
+			}
+			if got != tt.want {
+				t.Errorf("Primary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package domain
+
+import (
+	"strings"
+)
+
+// BaseLocale is the language of the untranslated HotelName, Details and
+// Amenities fields.
+const BaseLocale = "en"
+
+type LocalizedContent struct {
+	HotelName string    `json:"hotel_name,omitempty"`
+	Details   string    `json:"details,omitempty"`
+	Amenities Amenities `json:"amenities,omitempty"`
+}
+
+type LocaleSettings struct {
+	Default   string
+	Fallbacks map[string][]string
+}
+
+func NewLocaleSettings(defaultLocale string, fallbacks map[string][]string) LocaleSettings {
+	normalized := make(map[string][]string, len(fallbacks))
+	for locale, chain := range fallbacks {
+		normalized[NormalizeLocale(locale)] = chain
+	}
+
+	return LocaleSettings{
+		Default:   NormalizeLocale(defaultLocale),
+		Fallbacks: normalized,
+	}
+}
+
+func NormalizeLocale(tag string) string {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if tag == "" || tag == "*" {
+		return ""
+	}
+
+	parts := strings.Split(tag, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		} else {
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Chain expands the requested locales, most preferred first, into the order
+// in which translations are tried: each locale, its configured fallbacks, its
+// parent language, then the default and base locales.
+func (s LocaleSettings) Chain(requested []string) []string {
+	seen := make(map[string]bool)
+	var chain []string
+
+	var add func(locale string)
+	add = func(locale string) {
+		locale = NormalizeLocale(locale)
+		if locale == "" || seen[locale] {
+			return
+		}
+		seen[locale] = true
+		chain = append(chain, locale)
+
+		for _, fallback := range s.Fallbacks[locale] {
+			add(fallback)
+		}
+		if i := strings.Index(locale, "-"); i > 0 {
+			add(locale[:i])
+		}
+	}
+
+	for _, locale := range requested {
+		add(locale)
+	}
+	add(s.Default)
+	add(BaseLocale)
+
+	return chain
+}
+
+func (h *Hotel) baseContent() LocalizedContent {
+	return LocalizedContent{
+		HotelName: h.HotelName,
+		Details:   h.Details,
+		Amenities: h.Amenities,
+	}
+}
+
+func (h *Hotel) contentFor(locale string) LocalizedContent {
+	if locale == BaseLocale {
+		if h.Language != "" {
+			return LocalizedContent{}
+		}
+		return h.baseContent()
+	}
+	return h.Translations[locale]
+}
+
+// Localize returns a copy of the hotel whose text fields are taken, field by
+// field, from the first locale in chain that has them, and finally from the
+// base fields whatever their language. Translations are not carried over to
+// the copy.
+func (h *Hotel) Localize(chain []string) *Hotel {
+	localized := *h
+	localized.Translations = nil
+	localized.Language = ""
+	localized.HotelName = ""
+	localized.Details = ""
+	localized.Amenities = Amenities{}
+	localized.Locale = ""
+
+	apply := func(locale string, content LocalizedContent) {
+		if content.HotelName == "" && content.Details == "" &&
+			len(content.Amenities.General) == 0 && len(content.Amenities.Room) == 0 {
+			return
+		}
+
+		if localized.Locale == "" {
+			localized.Locale = locale
+		}
+		if localized.HotelName == "" {
+			localized.HotelName = content.HotelName
+		}
+		if localized.Details == "" {
+			localized.Details = content.Details
+		}
+		if len(localized.Amenities.General) == 0 {
+			localized.Amenities.General = content.Amenities.General
+		}
+		if len(localized.Amenities.Room) == 0 {
+			localized.Amenities.Room = content.Amenities.Room
+		}
+	}
+
+	for _, locale := range chain {
+		apply(locale, h.contentFor(locale))
+	}
+	if h.Language != "" {
+		apply(h.Language, h.baseContent())
+	}
+
+	return &localized
+}
+
+// moveToTranslation files text delivered in a non-base language under that
+// locale so it merges with other suppliers' translations. The base fields keep
+// the text as a fallback, with Language recording that it is not in the base
+// locale, so matching and quality scoring still see a name.
+func (h *Hotel) moveToTranslation() {
+	locale := NormalizeLocale(h.Language)
+	h.Language = ""
+	if locale == "" || locale == BaseLocale {
+		return
+	}
+	h.Language = locale
+
+	if h.Translations == nil {
+		h.Translations = make(map[string]LocalizedContent)
+	}
+	h.Translations[locale] = mergeLocalizedContent(h.Translations[locale], LocalizedContent{
+		HotelName: h.HotelName,
+		Details:   h.Details,
+		Amenities: h.Amenities,
+	})
+}
+
+func (h *Hotel) hasBaseText() bool {
+	return h.HotelName != "" || h.Details != "" ||
+		len(h.Amenities.General) > 0 || len(h.Amenities.Room) > 0
+}
+
+// mergeBaseText merges the base text fields of other into h. Text in different
+// languages is never mixed: base-locale text replaces a fallback in another
+// language, and a fallback only fills base fields that have no text.
+func (h *Hotel) mergeBaseText(other *Hotel) {
+	if h.Language != other.Language {
+		if (other.Language == "" && other.hasBaseText()) || !h.hasBaseText() {
+			h.HotelName = other.HotelName
+			h.Details = other.Details
+			h.Amenities = other.Amenities
+			h.Language = other.Language
+		}
+		return
+	}
+
+	if strings.TrimSpace(h.HotelName) == "" && strings.TrimSpace(other.HotelName) != "" {
+		h.HotelName = other.HotelName
+	}
+	if len(strings.TrimSpace(other.Details)) > len(strings.TrimSpace(h.Details)) {
+		h.Details = other.Details
+	}
+	h.Amenities.General = mergeStringSlices(h.Amenities.General, other.Amenities.General)
+	h.Amenities.Room = mergeStringSlices(h.Amenities.Room, other.Amenities.Room)
+}
+
+func cleanTranslations(translations map[string]LocalizedContent) map[string]LocalizedContent {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	result := make(map[string]LocalizedContent, len(translations))
+	for locale, content := range translations {
+		locale = NormalizeLocale(locale)
+		if locale == "" {
+			continue
+		}
+		result[locale] = mergeLocalizedContent(result[locale], LocalizedContent{
+			HotelName: SanitizeText(content.HotelName),
+			Details:   SanitizeText(content.Details),
+			Amenities: Amenities{
+				General: cleanStringSlice(content.Amenities.General),
+				Room:    cleanStringSlice(content.Amenities.Room),
+			},
+		})
+	}
+	return result
+}
+
+func mergeTranslations(a, b map[string]LocalizedContent) map[string]LocalizedContent {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	result := make(map[string]LocalizedContent, len(a)+len(b))
+	for locale, content := range a {
+		result[locale] = content
+	}
+	for locale, content := range b {
+		result[locale] = mergeLocalizedContent(result[locale], content)
+	}
+	return result
+}
+
+func mergeLocalizedContent(a, b LocalizedContent) LocalizedContent {
+	if a.HotelName == "" {
+		a.HotelName = b.HotelName
+	}
+	if len(b.Details) > len(a.Details) {
+		a.Details = b.Details
+	}
+	a.Amenities.General = mergeStringSlices(a.Amenities.General, b.Amenities.General)
+	a.Amenities.Room = mergeStringSlices(a.Amenities.Room, b.Amenities.Room)
+	return a
+}
@@ -0,0 +1,101 @@
+package domain
+
+import "testing"
+
+func TestNonBaseLanguageKeepsBaseFallback(t *testing.T) {
+	french := &Hotel{HotelID: "h", HotelName: "Villa de la Plage", Details: "Vue sur mer", Language: "fr"}
+	french.CleanData()
+
+	if french.HotelName != "Villa de la Plage" || french.Language != "fr" {
+		t.Fatalf("base fallback lost: name %q, language %q", french.HotelName, french.Language)
+	}
+	if french.Translations["fr"].HotelName != "Villa de la Plage" {
+		t.Fatalf("translation not filed: %+v", french.Translations)
+	}
+}
+
+func TestMergeBaseText(t *testing.T) {
+	tests := []struct {
+		name         string
+		first        *Hotel
+		second       *Hotel
+		wantName     string
+		wantLanguage string
+	}{
+		{
+			"base language replaces fallback",
+			&Hotel{HotelID: "h", HotelName: "Villa de la Plage", Language: "fr"},
+			&Hotel{HotelID: "h", HotelName: "Beach Villa"},
+			"Beach Villa", "",
+		},
+		{
+			"fallback does not replace base language",
+			&Hotel{HotelID: "h", HotelName: "Beach Villa"},
+			&Hotel{HotelID: "h", HotelName: "Villa de la Plage", Language: "fr"},
+			"Beach Villa", "",
+		},
+		{
+			"fallback fills empty base",
+			&Hotel{HotelID: "h"},
+			&Hotel{HotelID: "h", HotelName: "Villa de la Plage", Language: "fr"},
+			"Villa de la Plage", "fr",
+		},
+		{
+			"first fallback kept",
+			&Hotel{HotelID: "h", HotelName: "Villa de la Plage", Language: "fr"},
+			&Hotel{HotelID: "h", HotelName: "Strandvilla", Language: "de"},
+			"Villa de la Plage", "fr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.first.CleanData()
+			tt.second.CleanData()
+			tt.first.MergeWith(tt.second)
+
+			if tt.first.HotelName != tt.wantName || tt.first.Language != tt.wantLanguage {
+				t.Errorf("got name %q language %q, want %q %q",
+					tt.first.HotelName, tt.first.Language, tt.wantName, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	settings := NewLocaleSettings("en", nil)
+
+	french := &Hotel{HotelID: "h", HotelName: "Villa de la Plage", Language: "fr"}
+	french.CleanData()
+	english := &Hotel{HotelID: "h", HotelName: "Beach Villa"}
+	english.CleanData()
+	both := &Hotel{HotelID: "h", HotelName: "Beach Villa"}
+	both.CleanData()
+	both.MergeWith(french)
+
+	tests := []struct {
+		name       string
+		hotel      *Hotel
+		requested  []string
+		wantName   string
+		wantLocale string
+	}{
+		{"french only, french requested", french, []string{"fr-CA"}, "Villa de la Plage", "fr"},
+		{"french only, english requested", french, []string{"en"}, "Villa de la Plage", "fr"},
+		{"english only", english, []string{"fr"}, "Beach Villa", "en"},
+		{"both, french requested", both, []string{"fr"}, "Villa de la Plage", "fr"},
+		{"both, english requested", both, nil, "Beach Villa", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localized := tt.hotel.Localize(settings.Chain(tt.requested))
+			if localized.HotelName != tt.wantName || localized.Locale != tt.wantLocale {
+				t.Errorf("got %q (%s), want %q (%s)", localized.HotelName, localized.Locale, tt.wantName, tt.wantLocale)
+			}
+			if localized.Language != "" || localized.Translations != nil {
+				t.Errorf("localized copy kept language %q or translations", localized.Language)
+			}
+		})
+	}
+}
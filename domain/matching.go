@@ -0,0 +1,269 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	matchWeightName        = 0.45
+	matchWeightAddress     = 0.25
+	matchWeightCoordinates = 0.2
+	matchWeightDestination = 0.1
+
+	sameBuildingMeters = 100.0
+	farApartMeters     = 1000.0
+)
+
+var nameStopwords = map[string]bool{
+	"the": true, "hotel": true, "hotels": true, "and": true, "&": true,
+	"resort": true, "inn": true, "by": true, "at": true, "a": true,
+}
+
+type DuplicateCandidate struct {
+	HotelID     string             `json:"hotel_id"`
+	DuplicateID string             `json:"duplicate_id"`
+	Score       float64            `json:"score"`
+	Signals     map[string]float64 `json:"signals"`
+}
+
+type MatchRepository interface {
+	StoreDuplicateCandidates(ctx context.Context, candidates []DuplicateCandidate) error
+	GetDuplicateCandidates(ctx context.Context) ([]DuplicateCandidate, error)
+	// ApproveDuplicate records that duplicateID is merged into hotelID and
+	// deletes the stored duplicate: it is merged by the next fetch, and until
+	// then it must not be served as a hotel of its own.
+	ApproveDuplicate(ctx context.Context, hotelID, duplicateID string) error
+	RejectDuplicate(ctx context.Context, hotelID, duplicateID string) error
+	GetApprovedDuplicates(ctx context.Context) (HotelIDCrosswalk, error)
+	GetRejectedDuplicates(ctx context.Context) (map[string]bool, error)
+}
+
+// HotelIDCrosswalk maps a duplicate hotel ID to the canonical ID it was
+// approved to merge into.
+type HotelIDCrosswalk map[string]string
+
+func (c HotelIDCrosswalk) Resolve(hotelID string) string {
+	seen := make(map[string]bool)
+	for {
+		canonical, ok := c[hotelID]
+		if !ok || seen[canonical] {
+			return hotelID
+		}
+		seen[hotelID] = true
+		hotelID = canonical
+	}
+}
+
+func DuplicatePairKey(hotelID, duplicateID string) string {
+	if duplicateID < hotelID {
+		hotelID, duplicateID = duplicateID, hotelID
+	}
+	return fmt.Sprintf("%s|%s", hotelID, duplicateID)
+}
+
+// matchCellDegrees is the size of the coordinate cells hotels are blocked on,
+// about 1km of latitude, so hotels close enough to score on coordinates are in
+// the same or a neighbouring cell.
+const matchCellDegrees = 0.01
+
+// matchProfile holds what ScoreDuplicate compares, normalized once per hotel.
+type matchProfile struct {
+	hotel   *Hotel
+	name    map[string]bool
+	address map[string]bool
+}
+
+func newMatchProfile(hotel *Hotel) *matchProfile {
+	profile := &matchProfile{hotel: hotel, name: normalizeMatchTokens(hotel.HotelName, true)}
+	if hotel.Location.Address != "" {
+		profile.address = normalizeMatchTokens(hotel.Location.Address, false)
+	}
+	return profile
+}
+
+type matchCell struct {
+	lat, lng int
+}
+
+func matchCellOf(location Location) matchCell {
+	return matchCell{
+		lat: int(math.Floor(location.Lat / matchCellDegrees)),
+		lng: int(math.Floor(location.Lng / matchCellDegrees)),
+	}
+}
+
+// FindDuplicateCandidates compares hotels that share a destination or sit in
+// the same or a neighbouring ~1km coordinate cell and returns pairs scoring at
+// least threshold, best first. Pairs listed in skip (by DuplicatePairKey) are
+// ignored.
+func FindDuplicateCandidates(hotels []*Hotel, threshold float64, skip map[string]bool) []DuplicateCandidate {
+	profiles := make([]*matchProfile, len(hotels))
+	destinations := make(map[int][]int)
+	cells := make(map[matchCell][]int)
+	for i, hotel := range hotels {
+		profiles[i] = newMatchProfile(hotel)
+		if hotel.DestinationID > 0 {
+			destinations[hotel.DestinationID] = append(destinations[hotel.DestinationID], i)
+		}
+		if hotel.Location.HasCoordinates() {
+			cell := matchCellOf(hotel.Location)
+			cells[cell] = append(cells[cell], i)
+		}
+	}
+
+	compared := make(map[[2]int]bool)
+	var candidates []DuplicateCandidate
+	compare := func(i, j int) {
+		if i == j {
+			return
+		}
+		if j < i {
+			i, j = j, i
+		}
+		a, b := profiles[i], profiles[j]
+		if compared[[2]int{i, j}] || a.hotel.HotelID == b.hotel.HotelID {
+			return
+		}
+		compared[[2]int{i, j}] = true
+		if skip[DuplicatePairKey(a.hotel.HotelID, b.hotel.HotelID)] {
+			return
+		}
+
+		candidate := scoreMatchProfiles(a, b)
+		if candidate.Score >= threshold {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for _, block := range destinations {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				compare(block[x], block[y])
+			}
+		}
+	}
+	for cell, block := range cells {
+		for dLat := -1; dLat <= 1; dLat++ {
+			for dLng := -1; dLng <= 1; dLng++ {
+				for _, i := range block {
+					for _, j := range cells[matchCell{lat: cell.lat + dLat, lng: cell.lng + dLng}] {
+						compare(i, j)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return DuplicatePairKey(candidates[i].HotelID, candidates[i].DuplicateID) <
+			DuplicatePairKey(candidates[j].HotelID, candidates[j].DuplicateID)
+	})
+
+	return candidates
+}
+
+func ScoreDuplicate(a, b *Hotel) DuplicateCandidate {
+	return scoreMatchProfiles(newMatchProfile(a), newMatchProfile(b))
+}
+
+func scoreMatchProfiles(pa, pb *matchProfile) DuplicateCandidate {
+	a, b := pa.hotel, pb.hotel
+	hotelID, duplicateID := a.HotelID, b.HotelID
+	if duplicateID < hotelID {
+		hotelID, duplicateID = duplicateID, hotelID
+	}
+
+	signals := map[string]float64{
+		"name": tokenSimilarity(pa.name, pb.name),
+	}
+	totalWeight := matchWeightName
+	score := matchWeightName * signals["name"]
+
+	if pa.address != nil && pb.address != nil {
+		signals["address"] = tokenSimilarity(pa.address, pb.address)
+		totalWeight += matchWeightAddress
+		score += matchWeightAddress * signals["address"]
+	}
+
+	if a.Location.HasCoordinates() && b.Location.HasCoordinates() {
+		signals["coordinates"] = proximityScore(distanceMeters(a.Location, b.Location))
+		totalWeight += matchWeightCoordinates
+		score += matchWeightCoordinates * signals["coordinates"]
+	}
+
+	if a.DestinationID > 0 && b.DestinationID > 0 {
+		signals["destination"] = 0
+		if a.DestinationID == b.DestinationID {
+			signals["destination"] = 1
+		}
+		totalWeight += matchWeightDestination
+		score += matchWeightDestination * signals["destination"]
+	}
+
+	return DuplicateCandidate{
+		HotelID:     hotelID,
+		DuplicateID: duplicateID,
+		Score:       math.Round(score/totalWeight*1000) / 1000,
+		Signals:     signals,
+	}
+}
+
+func normalizeMatchTokens(s string, dropStopwords bool) map[string]bool {
+	s = strings.ToLower(SanitizeText(s))
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if dropStopwords && nameStopwords[field] {
+			continue
+		}
+		tokens[field] = true
+	}
+	return tokens
+}
+
+func tokenSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+func proximityScore(meters float64) float64 {
+	switch {
+	case meters <= sameBuildingMeters:
+		return 1
+	case meters >= farApartMeters:
+		return 0
+	default:
+		return 1 - (meters-sameBuildingMeters)/(farApartMeters-sameBuildingMeters)
+	}
+}
+
+func distanceMeters(a, b Location) float64 {
+	const earthRadiusMeters = 6371000.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Lat - a.Lat)
+	dLng := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
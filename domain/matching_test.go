@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScoreDuplicate(t *testing.T) {
+	grand := &Hotel{HotelID: "a", DestinationID: 1, HotelName: "The Grand Hotel",
+		Location: Location{Address: "1 Orchard Rd", Lat: 1.3, Lng: 103.8}}
+
+	tests := []struct {
+		name    string
+		other   *Hotel
+		score   float64
+		signals string
+	}{
+		{"same hotel elsewhere", &Hotel{HotelID: "b", DestinationID: 1, HotelName: "Grand",
+			Location: Location{Address: "1 orchard rd.", Lat: 1.3, Lng: 103.8}},
+			1, "map[address:1 coordinates:1 destination:1 name:1]"},
+		{"no address or coordinates", &Hotel{HotelID: "b", DestinationID: 1, HotelName: "Grand Hotel"},
+			1, "map[destination:1 name:1]"},
+		{"other destination", &Hotel{HotelID: "b", DestinationID: 2, HotelName: "Grand"},
+			0.818, "map[destination:0 name:1]"},
+		{"different name next door", &Hotel{HotelID: "b", DestinationID: 1, HotelName: "Harbour View",
+			Location: Location{Address: "3 Orchard Rd", Lat: 1.3, Lng: 103.8}},
+			0.467, "map[address:0.6666666666666666 coordinates:1 destination:1 name:0]"},
+		{"same name 1km away", &Hotel{HotelID: "b", HotelName: "Grand",
+			Location: northBy1km(grand.Location)},
+			0.692, "map[coordinates:0 name:1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := ScoreDuplicate(grand, tt.other)
+			if candidate.HotelID != "a" || candidate.DuplicateID != "b" {
+				t.Errorf("pair = %s, %s", candidate.HotelID, candidate.DuplicateID)
+			}
+			if candidate.Score != tt.score {
+				t.Errorf("score = %v, want %v", candidate.Score, tt.score)
+			}
+			if got := fmt.Sprint(candidate.Signals); got != tt.signals {
+				t.Errorf("signals = %s, want %s", got, tt.signals)
+			}
+		})
+	}
+}
+
+// northBy1km returns a location about 1.1km north of location.
+func northBy1km(location Location) Location {
+	return Location{Lat: location.Lat + 0.01, Lng: location.Lng}
+}
+
+func TestFindDuplicateCandidates(t *testing.T) {
+	hotels := []*Hotel{
+		// A few metres apart on either side of a cell boundary, without a
+		// destination to share.
+		{HotelID: "north", HotelName: "Marina Bay Suites", Location: Location{Lat: 1.30001, Lng: 103.85}},
+		{HotelID: "south", HotelName: "Marina Bay Suites", Location: Location{Lat: 1.29999, Lng: 103.85}},
+		// Same name, 50km away and in no shared destination.
+		{HotelID: "far", HotelName: "Marina Bay Suites", Location: Location{Lat: 1.75, Lng: 103.85}},
+		// Shared destination, no coordinates.
+		{HotelID: "d1", DestinationID: 7, HotelName: "Beach Resort Kuta"},
+		{HotelID: "d2", DestinationID: 7, HotelName: "Kuta Beach Resort"},
+		{HotelID: "d3", DestinationID: 7, HotelName: "Mountain Lodge"},
+		{HotelID: "skipped", DestinationID: 7, HotelName: "Kuta Beach"},
+	}
+	skip := map[string]bool{
+		DuplicatePairKey("d1", "skipped"): true,
+		DuplicatePairKey("skipped", "d2"): true,
+	}
+
+	var got []string
+	for _, candidate := range FindDuplicateCandidates(hotels, 0.8, skip) {
+		got = append(got, fmt.Sprintf("%s|%s %.3f", candidate.HotelID, candidate.DuplicateID, candidate.Score))
+	}
+	want := "[d1|d2 1.000 north|south 1.000]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("candidates = %v, want %s", got, want)
+	}
+}
+
+func TestFindDuplicateCandidatesAcrossNegativeCells(t *testing.T) {
+	hotels := []*Hotel{
+		{HotelID: "a", HotelName: "Copacabana Palace", Location: Location{Lat: -22.96701, Lng: -43.17999}},
+		{HotelID: "b", HotelName: "Copacabana Palace", Location: Location{Lat: -22.96699, Lng: -43.18001}},
+	}
+	if candidates := FindDuplicateCandidates(hotels, 0.9, nil); len(candidates) != 1 {
+		t.Errorf("candidates = %+v, want the pair across the cell corner", candidates)
+	}
+}
@@ -0,0 +1,231 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policies are structured facts extracted from BookingConditions. Pointer
+// fields stay nil when no condition mentions the topic.
+type Policies struct {
+	CheckInTime           string `json:"check_in_time,omitempty"`
+	CheckOutTime          string `json:"check_out_time,omitempty"`
+	Refundable            *bool  `json:"refundable,omitempty"`
+	FreeCancellationHours int    `json:"free_cancellation_hours,omitempty"`
+	PetsAllowed           *bool  `json:"pets_allowed,omitempty"`
+	SmokingAllowed        *bool  `json:"smoking_allowed,omitempty"`
+	ChildrenAllowed       *bool  `json:"children_allowed,omitempty"`
+	ChildrenFreeUnderAge  int    `json:"children_free_under_age,omitempty"`
+	ExtraBedsAvailable    *bool  `json:"extra_beds_available,omitempty"`
+	DepositRequired       *bool  `json:"deposit_required,omitempty"`
+	DepositAmount         string `json:"deposit_amount,omitempty"`
+}
+
+var (
+	timePattern         = regexp.MustCompile(`\b(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?`)
+	checkInPattern      = regexp.MustCompile(`check[\s-]?in`)
+	checkOutPattern     = regexp.MustCompile(`check[\s-]?out`)
+	cancellationPattern = regexp.MustCompile(`(\d+)\s*(hours?|hrs?|days?)`)
+	childAgePattern     = regexp.MustCompile(`(?:under|below|younger than|up to)\s*(?:the age of\s*)?(\d{1,2})`)
+	depositPattern      = regexp.MustCompile(`(?:(?:us|s|sg)?\$|usd|sgd|eur|€|£|gbp)\s?\d+(?:[.,]\d+)?|\d+(?:[.,]\d+)?\s?(?:usd|sgd|eur|gbp)`)
+)
+
+// Phrases match whole words; a trailing '*' also matches longer words starting
+// with the phrase (see containsAny).
+var (
+	petsDenied     = []string{"no pets", "pets are not", "pets not", "pets aren't", "no animals", "animals are not", "pets are prohibited"}
+	petsAllowed    = []string{"pets allowed", "pets are allowed", "pet-friendly", "pet friendly", "pets are welcome", "pets welcome"}
+	smokingDenied  = []string{"non-smoking", "no smoking", "smoking is not", "smoking not", "smoke-free", "smoke free", "smoking is prohibited", "smoking is strictly prohibited"}
+	smokingAllowed = []string{"smoking allowed", "smoking is allowed", "smoking room*", "smoking area*", "smoking permitted"}
+	childrenDenied = []string{"adults only", "adult only", "children are not", "children not", "no children", "kids are not"}
+	childrenAllow  = []string{"children are welcome", "children welcome", "children of all ages", "kids are welcome", "family-friendly", "children stay free", "children are allowed"}
+	extraBedDenied = []string{"no extra bed*", "extra beds are not", "extra beds not", "extra bed is not", "no rollaway*"}
+	extraBedAllow  = []string{"extra bed*", "rollaway*", "baby cot*", "cot", "cots", "crib", "cribs"}
+	depositDenied  = []string{"no deposit*", "deposit is not required", "deposit not required"}
+	depositAllow   = []string{"deposit*", "pre-authori*", "security hold*"}
+	nonRefundable  = []string{"non-refundable", "nonrefundable", "no refund*", "not refundable"}
+	childWords     = []string{"child*", "kid*"}
+)
+
+func ExtractPolicies(conditions []string) Policies {
+	var p Policies
+
+	for _, condition := range conditions {
+		text := strings.ToLower(condition)
+
+		if p.CheckInTime == "" {
+			p.CheckInTime = timeAfter(text, checkInPattern)
+		}
+		if p.CheckOutTime == "" {
+			p.CheckOutTime = timeAfter(text, checkOutPattern)
+		}
+
+		// The first condition to settle Refundable wins; a later cancellation
+		// window never makes a non-refundable rate refundable.
+		if strings.Contains(text, "cancel") || strings.Contains(text, "refund") {
+			if containsAny(text, nonRefundable) {
+				if p.Refundable == nil {
+					p.Refundable = boolPtr(false)
+				}
+			} else if p.FreeCancellationHours == 0 {
+				if hours := cancellationHours(text); hours > 0 {
+					p.FreeCancellationHours = hours
+					if p.Refundable == nil {
+						p.Refundable = boolPtr(true)
+					}
+				}
+			}
+		}
+
+		if p.PetsAllowed == nil {
+			p.PetsAllowed = classify(text, petsDenied, petsAllowed)
+		}
+		if p.SmokingAllowed == nil {
+			p.SmokingAllowed = classify(text, smokingDenied, smokingAllowed)
+		}
+
+		if p.ChildrenAllowed == nil {
+			p.ChildrenAllowed = classify(text, childrenDenied, childrenAllow)
+		}
+		if p.ChildrenFreeUnderAge == 0 && containsAny(text, childWords) && containsAny(text, []string{"free"}) {
+			if match := childAgePattern.FindStringSubmatch(text); match != nil {
+				p.ChildrenFreeUnderAge, _ = strconv.Atoi(match[1])
+				p.ChildrenAllowed = boolPtr(true)
+			}
+		}
+
+		if p.ExtraBedsAvailable == nil {
+			p.ExtraBedsAvailable = classify(text, extraBedDenied, extraBedAllow)
+		}
+
+		if p.DepositRequired == nil {
+			p.DepositRequired = classify(text, depositDenied, depositAllow)
+			if p.DepositRequired != nil && *p.DepositRequired {
+				p.DepositAmount = strings.ToUpper(depositPattern.FindString(text))
+			}
+		}
+	}
+
+	return p
+}
+
+// timeAfter returns the first clock time following keyword in text as HH:MM.
+func timeAfter(text string, keyword *regexp.Regexp) string {
+	loc := keyword.FindStringIndex(text)
+	if loc == nil {
+		return ""
+	}
+
+	rest := text[loc[1]:]
+	if next := otherKeywordIndex(rest, keyword); next >= 0 {
+		rest = rest[:next]
+	}
+
+	for _, match := range timePattern.FindAllStringSubmatch(rest, -1) {
+		hour, _ := strconv.Atoi(match[1])
+		minute := 0
+		if match[2] != "" {
+			minute, _ = strconv.Atoi(match[2])
+		}
+		if match[2] == "" && match[3] == "" {
+			continue
+		}
+
+		switch strings.ReplaceAll(match[3], ".", "") {
+		case "pm":
+			if hour < 12 {
+				hour += 12
+			}
+		case "am":
+			if hour == 12 {
+				hour = 0
+			}
+		}
+
+		if hour < 24 && minute < 60 {
+			return fmt.Sprintf("%02d:%02d", hour, minute)
+		}
+	}
+
+	return ""
+}
+
+func otherKeywordIndex(text string, keyword *regexp.Regexp) int {
+	other := checkOutPattern
+	if keyword == checkOutPattern {
+		other = checkInPattern
+	}
+	if loc := other.FindStringIndex(text); loc != nil {
+		return loc[0]
+	}
+	return -1
+}
+
+func cancellationHours(text string) int {
+	match := cancellationPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+
+	value, _ := strconv.Atoi(match[1])
+	if strings.HasPrefix(match[2], "day") {
+		value *= 24
+	}
+	return value
+}
+
+func classify(text string, denied, allowed []string) *bool {
+	if containsAny(text, denied) {
+		return boolPtr(false)
+	}
+	if containsAny(text, allowed) {
+		return boolPtr(true)
+	}
+	return nil
+}
+
+// containsAny reports whether text contains one of phrases as whole words, so
+// "crib" does not match "describe". A phrase ending in '*' may be followed by
+// more letters: "deposit*" matches "deposits".
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if containsWords(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWords(text, phrase string) bool {
+	prefix := strings.HasSuffix(phrase, "*")
+	phrase = strings.TrimSuffix(phrase, "*")
+
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], phrase)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(phrase)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && (prefix || !isWordRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExtractPolicies(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []string
+		want       string
+	}{
+		{"none", nil, `{}`},
+		{"check-in and check-out",
+			[]string{"Check-in from 3pm, check-out until 11:00"},
+			`{"check_in_time":"15:00","check_out_time":"11:00"}`},
+		{"cancellation window",
+			[]string{"Free cancellation up to 2 days before arrival"},
+			`{"refundable":true,"free_cancellation_hours":48}`},
+		{"non-refundable",
+			[]string{"This rate is non-refundable and cannot be cancelled"},
+			`{"refundable":false}`},
+		{"later window keeps non-refundable",
+			[]string{"No refunds on cancellation", "Cancellation is free up to 24 hours before arrival"},
+			`{"refundable":false,"free_cancellation_hours":24}`},
+		{"pets and smoking",
+			[]string{"No pets allowed", "Smoking is allowed in designated smoking areas"},
+			`{"pets_allowed":false,"smoking_allowed":true}`},
+		{"non-smoking", []string{"Non-smoking rooms only"}, `{"smoking_allowed":false}`},
+		{"children free under age",
+			[]string{"Kids under 12 stay free"},
+			`{"children_allowed":true,"children_free_under_age":12}`},
+		{"adults only", []string{"Adults only resort"}, `{"children_allowed":false}`},
+		{"cribs", []string{"Cribs available on request"}, `{"extra_beds_available":true}`},
+		{"no extra beds", []string{"No extra beds in deluxe rooms"}, `{"extra_beds_available":false}`},
+		{"deposit",
+			[]string{"A deposit of SGD 100 is required at check-in"},
+			`{"deposit_required":true,"deposit_amount":"SGD 100"}`},
+		{"deposits plural", []string{"Deposits are collected on arrival"}, `{"deposit_required":true}`},
+		{"no deposit", []string{"No deposit required"}, `{"deposit_required":false}`},
+		{"crib inside describe",
+			[]string{"Please describe any special requests"},
+			`{}`},
+		{"cots inside apricots and mascots",
+			[]string{"Welcome apricots and team mascots in the lobby"},
+			`{}`},
+		{"pets inside carpets",
+			[]string{"Carpets not allowed to be moved"},
+			`{}`},
+		{"deposit inside redeposited",
+			[]string{"Luggage can be redeposited at the front desk"},
+			`{}`},
+		{"smoke free inside smoke freely",
+			[]string{"Guests may not smoke freely indoors; smoking rooms available"},
+			`{"smoking_allowed":true}`},
+		{"kid inside skid",
+			[]string{"Non-skid mats free on request up to 3 per room"},
+			`{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(ExtractPolicies(tt.conditions))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ExtractPolicies(%q) = %s, want %s", tt.conditions, got, tt.want)
+			}
+		})
+	}
+}
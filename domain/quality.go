@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	QualityComponentName        = "name"
+	QualityComponentAddress     = "address"
+	QualityComponentCoordinates = "coordinates"
+	QualityComponentDescription = "description"
+	QualityComponentImages      = "images"
+	QualityComponentAmenities   = "amenities"
+	QualityComponentAgreement   = "agreement"
+)
+
+type Quality struct {
+	Score      float64            `json:"score"`
+	Components map[string]float64 `json:"components"`
+}
+
+// QualityModel weighs each completeness component (each scored 0-1) into a
+// 0-100 score. Targets set the value at which a graded component is full.
+type QualityModel struct {
+	Weights                 map[string]float64
+	DescriptionTargetLength int
+	ImageTargetCount        int
+	AmenityTargetCount      int
+}
+
+type QualityReport struct {
+	RunAt   time.Time      `json:"run_at"`
+	Count   int            `json:"count"`
+	Min     float64        `json:"min"`
+	Max     float64        `json:"max"`
+	Mean    float64        `json:"mean"`
+	Median  float64        `json:"median"`
+	Buckets map[string]int `json:"buckets"`
+}
+
+type QualityRepository interface {
+	StoreQualityReport(ctx context.Context, report QualityReport) error
+	GetQualityReport(ctx context.Context) (*QualityReport, error)
+}
+
+func DefaultQualityModel() QualityModel {
+	return QualityModel{
+		Weights: map[string]float64{
+			QualityComponentName:        0.15,
+			QualityComponentAddress:     0.1,
+			QualityComponentCoordinates: 0.15,
+			QualityComponentDescription: 0.15,
+			QualityComponentImages:      0.15,
+			QualityComponentAmenities:   0.1,
+			QualityComponentAgreement:   0.2,
+		},
+		DescriptionTargetLength: 500,
+		ImageTargetCount:        10,
+		AmenityTargetCount:      10,
+	}
+}
+
+func (m QualityModel) Validate() error {
+	total := 0.0
+	for component, weight := range m.Weights {
+		switch component {
+		case QualityComponentName, QualityComponentAddress, QualityComponentCoordinates,
+			QualityComponentDescription, QualityComponentImages, QualityComponentAmenities,
+			QualityComponentAgreement:
+		default:
+			return fmt.Errorf("unknown quality component: %s", component)
+		}
+		if weight < 0 {
+			return fmt.Errorf("quality weight for %s must not be negative", component)
+		}
+		total += weight
+	}
+	if total <= 0 {
+		return fmt.Errorf("at least one quality weight must be positive")
+	}
+	if m.DescriptionTargetLength <= 0 || m.ImageTargetCount <= 0 || m.AmenityTargetCount <= 0 {
+		return fmt.Errorf("quality targets must be positive")
+	}
+	return nil
+}
+
+func (m QualityModel) Score(h *Hotel) Quality {
+	imageCount := 0
+	for _, images := range h.Images {
+		for _, img := range images {
+			if !img.IsDead() {
+				imageCount++
+			}
+		}
+	}
+
+	components := map[string]float64{
+		QualityComponentName:        presence(h.HotelName != ""),
+		QualityComponentAddress:     presence(h.Location.Address != ""),
+		QualityComponentCoordinates: presence(h.Location.HasCoordinates()),
+		QualityComponentDescription: graded(len(h.Details), m.DescriptionTargetLength),
+		QualityComponentImages:      graded(imageCount, m.ImageTargetCount),
+		QualityComponentAmenities:   graded(len(h.Amenities.General)+len(h.Amenities.Room), m.AmenityTargetCount),
+		QualityComponentAgreement:   h.supplierAgreement(),
+	}
+
+	var weighted, totalWeight float64
+	for component, value := range components {
+		weight := m.Weights[component]
+		weighted += weight * value
+		totalWeight += weight
+	}
+
+	score := 0.0
+	if totalWeight > 0 {
+		score = math.Round(weighted/totalWeight*1000) / 10
+	}
+
+	return Quality{Score: score, Components: components}
+}
+
+func (h *Hotel) QualityScore() float64 {
+	if h.Quality == nil {
+		return 0
+	}
+	return h.Quality.Score
+}
+
+// recordAgreement compares the name, address and coordinates of a supplier
+// record merged into h, before h is filled in from it. Text is only compared
+// between records in the same language.
+func (h *Hotel) recordAgreement(other *Hotel) {
+	if h.Language == other.Language {
+		if h.HotelName != "" && other.HotelName != "" {
+			h.agreements = append(h.agreements, tokenSimilarity(
+				normalizeMatchTokens(h.HotelName, true),
+				normalizeMatchTokens(other.HotelName, true),
+			))
+		}
+		if h.Location.Address != "" && other.Location.Address != "" {
+			h.agreements = append(h.agreements, tokenSimilarity(
+				normalizeMatchTokens(h.Location.Address, false),
+				normalizeMatchTokens(other.Location.Address, false),
+			))
+		}
+	}
+	if h.Location.HasCoordinates() && other.Location.HasCoordinates() {
+		h.agreements = append(h.agreements, proximityScore(distanceMeters(h.Location, other.Location)))
+	}
+}
+
+// supplierAgreement is the mean of the name, address and coordinate agreements
+// between merged supplier records. Hotels reloaded from storage keep their
+// previously computed value; single-supplier hotels have nothing corroborating
+// them and score 0.
+func (h *Hotel) supplierAgreement() float64 {
+	if len(h.agreements) == 0 {
+		if h.Quality != nil {
+			return h.Quality.Components[QualityComponentAgreement]
+		}
+		return 0
+	}
+
+	total := 0.0
+	for _, agreement := range h.agreements {
+		total += agreement
+	}
+	return math.Round(total/float64(len(h.agreements))*1000) / 1000
+}
+
+func NewQualityReport(scores []float64) QualityReport {
+	report := QualityReport{
+		RunAt:   time.Now(),
+		Count:   len(scores),
+		Buckets: make(map[string]int),
+	}
+	if len(scores) == 0 {
+		return report
+	}
+
+	sorted := append([]float64{}, scores...)
+	sort.Float64s(sorted)
+
+	total := 0.0
+	for _, score := range sorted {
+		total += score
+		bucket := int(score/10) * 10
+		if bucket >= 100 {
+			bucket = 90
+		}
+		report.Buckets[fmt.Sprintf("%d-%d", bucket, bucket+10)]++
+	}
+
+	report.Min = sorted[0]
+	report.Max = sorted[len(sorted)-1]
+	report.Mean = math.Round(total/float64(len(sorted))*10) / 10
+	if len(sorted)%2 == 1 {
+		report.Median = sorted[len(sorted)/2]
+	} else {
+		report.Median = math.Round((sorted[len(sorted)/2-1]+sorted[len(sorted)/2])/2*10) / 10
+	}
+
+	return report
+}
+
+func presence(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func graded(value, target int) float64 {
+	if value >= target {
+		return 1
+	}
+	return math.Round(float64(value)/float64(target)*1000) / 1000
+}
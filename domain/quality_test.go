@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDefaultQualityModel(t *testing.T) {
+	model := DefaultQualityModel()
+	if err := model.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	total := 0.0
+	for _, weight := range model.Weights {
+		total += weight
+	}
+	if fmt.Sprintf("%.6f", total) != "1.000000" {
+		t.Errorf("weights sum to %v, want 1", total)
+	}
+}
+
+func TestQualityModelScore(t *testing.T) {
+	dead := &ImageHealth{Alive: false}
+	var images []Image
+	for i := 0; i < 7; i++ {
+		img := Image{Link: fmt.Sprintf("https://example.com/%d.jpg", i)}
+		if i >= 5 {
+			img.Health = dead
+		}
+		images = append(images, img)
+	}
+	hotel := &Hotel{
+		HotelID:   "a",
+		HotelName: "Grand Hotel",
+		Location:  Location{Address: "1 Orchard Rd", Lat: 1.3, Lng: 103.8},
+		Details:   strings.Repeat("x", 250),
+		Amenities: Amenities{General: strings.Fields("a b c d e f"), Room: strings.Fields("g h i j")},
+		Images:    Images{"rooms": images},
+	}
+	hotel.MergeWith(&Hotel{HotelID: "a", HotelName: "Grand", Location: hotel.Location})
+
+	quality := DefaultQualityModel().Score(hotel)
+	if quality.Score != 85 {
+		t.Errorf("score = %v, want 85", quality.Score)
+	}
+	want := "map[address:1 agreement:1 amenities:1 coordinates:1 description:0.5 images:0.5 name:1]"
+	if got := fmt.Sprint(quality.Components); got != want {
+		t.Errorf("components = %s, want %s", got, want)
+	}
+}
+
+func TestSupplierAgreement(t *testing.T) {
+	base := Hotel{HotelName: "Grand Hotel", Location: Location{Address: "1 Orchard Rd", Lat: 1.3, Lng: 103.8}}
+
+	tests := []struct {
+		name  string
+		other Hotel
+		want  float64
+	}{
+		{"single supplier", Hotel{}, 0},
+		{"full agreement", base, 1},
+		{"different address, 1km away",
+			Hotel{HotelName: "Grand", Location: Location{Address: "3 Orchard Rd", Lat: 1.31, Lng: 103.8}},
+			0.556},
+		{"same name, 550m away, no address",
+			Hotel{HotelName: "The Grand", Location: Location{Lat: 1.305, Lng: 103.8}},
+			0.747},
+		{"other language compares coordinates only",
+			Hotel{HotelName: "Gran Hotel", Language: "es", Location: Location{Address: "Calle 1", Lat: 1.3, Lng: 103.8}},
+			1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotel := base
+			if tt.other.HotelName != "" {
+				hotel.MergeWith(&tt.other)
+			}
+			if got := hotel.supplierAgreement(); got != tt.want {
+				t.Errorf("agreement = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewQualityReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []float64
+		want   string
+	}{
+		{"empty", nil, "0 0 0 0 0 map[]"},
+		{"odd", []float64{70, 12.5, 100}, "3 12.5 100 60.8 70 map[10-20:1 70-80:1 90-100:1]"},
+		{"even", []float64{40, 55, 90, 20}, "4 20 90 51.3 47.5 map[20-30:1 40-50:1 50-60:1 90-100:1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewQualityReport(tt.scores)
+			if report.RunAt.IsZero() {
+				t.Error("run_at not set")
+			}
+			got := fmt.Sprint(report.Count, report.Min, report.Max, report.Mean, report.Median, report.Buckets)
+			if got != tt.want {
+				t.Errorf("report = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
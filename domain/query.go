@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var hotelSortKeys = map[string]func(h *Hotel) float64{
+	"stars":        func(h *Hotel) float64 { return h.Rating.Stars },
+	"review_score": func(h *Hotel) float64 { return h.Rating.ReviewScore },
+	"review_count": func(h *Hotel) float64 { return float64(h.Rating.ReviewCount) },
+	"quality":      func(h *Hotel) float64 { return h.QualityScore() },
+}
+
+type HotelQuery struct {
+	MinStars       float64
+	MinReviewScore float64
+	MinQuality     float64
+	SortBy         string
+	Descending     bool
+}
+
+// ParseHotelSort accepts a sort key optionally prefixed with "-" for
+// descending order, e.g. "-review_score".
+func ParseHotelSort(value string) (string, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", false, nil
+	}
+
+	descending := strings.HasPrefix(value, "-")
+	key := strings.TrimPrefix(value, "-")
+	if _, ok := hotelSortKeys[key]; !ok {
+		return "", false, fmt.Errorf("unsupported sort key: %s", key)
+	}
+
+	return key, descending, nil
+}
+
+func (q HotelQuery) Apply(hotels []*Hotel) []*Hotel {
+	result := make([]*Hotel, 0, len(hotels))
+	for _, hotel := range hotels {
+		if q.MinStars > 0 && hotel.Rating.Stars < q.MinStars {
+			continue
+		}
+		if q.MinReviewScore > 0 && hotel.Rating.ReviewScore < q.MinReviewScore {
+			continue
+		}
+		if q.MinQuality > 0 && hotel.QualityScore() < q.MinQuality {
+			continue
+		}
+		result = append(result, hotel)
+	}
+
+	if key, ok := hotelSortKeys[q.SortBy]; ok {
+		sort.SliceStable(result, func(i, j int) bool {
+			if q.Descending {
+				return key(result[i]) > key(result[j])
+			}
+			return key(result[i]) < key(result[j])
+		})
+	}
+
+	return result
+}
+
+// Page selects a window of an ordered result. A zero Limit means no limit.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// Bounds returns the start and end indexes of the page within total items.
+func (p Page) Bounds(total int) (int, int) {
+	start := p.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if p.Limit > 0 && start+p.Limit < total {
+		end = start + p.Limit
+	}
+	return start, end
+}
+
+// PageHotels returns the slice of hotels that falls within page.
+func PageHotels(hotels []*Hotel, page Page) []*Hotel {
+	start, end := page.Bounds(len(hotels))
+	return hotels[start:end]
+}
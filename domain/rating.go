@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+const (
+	ReviewScoreScale = 10.0
+	MaxStars         = 5.0
+
+	RatingMergeWeighted = "weighted"
+	RatingMergeAverage  = "average"
+	RatingMergeMax      = "max"
+)
+
+// Rating holds star classification (0.5 steps, up to 5) and the guest review
+// score normalized to a 10-point scale. ReviewScale is always written so that
+// stored ratings are not re-scaled when read back; a score read without one is
+// kept as delivered until ApplyReviewScale normalizes it.
+type Rating struct {
+	Stars       float64 `json:"stars,omitempty"`
+	ReviewScore float64 `json:"review_score,omitempty"`
+	ReviewScale float64 `json:"review_scale,omitempty"`
+	ReviewCount int     `json:"review_count,omitempty"`
+
+	samples []Rating
+}
+
+func (r *Rating) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Stars       float64 `json:"stars"`
+		StarRating  float64 `json:"star_rating"`
+		ReviewScore float64 `json:"review_score"`
+		ReviewScale float64 `json:"review_scale"`
+		ReviewCount int     `json:"review_count"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.Stars = raw.Stars
+	if r.Stars == 0 {
+		r.Stars = raw.StarRating
+	}
+	r.ReviewScore = raw.ReviewScore
+	if raw.ReviewScale > 0 {
+		r.ReviewScore = normalizeReviewScore(raw.ReviewScore, raw.ReviewScale)
+		r.ReviewScale = reviewScaleFor(r.ReviewScore)
+	}
+	r.ReviewCount = raw.ReviewCount
+
+	return nil
+}
+
+func (r Rating) IsZero() bool {
+	return r.Stars == 0 && r.ReviewScore == 0 && r.ReviewCount == 0
+}
+
+func (r Rating) Validate() error {
+	if r.Stars < 0 || r.Stars > MaxStars {
+		return fmt.Errorf("stars must be between 0 and %.0f", MaxStars)
+	}
+	if r.ReviewScore < 0 || r.ReviewScore > ReviewScoreScale {
+		return fmt.Errorf("review score must be between 0 and %.0f", ReviewScoreScale)
+	}
+	if r.ReviewCount < 0 {
+		return fmt.Errorf("review count must not be negative")
+	}
+	return nil
+}
+
+// HasUnscaledReviewScore reports whether the review score was delivered
+// without its scale.
+func (r Rating) HasUnscaledReviewScore() bool {
+	return r.ReviewScore > 0 && r.ReviewScale == 0
+}
+
+// ApplyReviewScale normalizes a review score delivered without its scale
+// using the supplier's configured scale. Without a scale the score cannot be
+// interpreted and is dropped; ApplyReviewScale then returns false.
+func (r *Rating) ApplyReviewScale(scale float64) bool {
+	if !r.HasUnscaledReviewScore() {
+		return true
+	}
+	if scale <= 0 {
+		r.ReviewScore = 0
+		return false
+	}
+
+	r.ReviewScore = normalizeReviewScore(r.ReviewScore, scale)
+	r.ReviewScale = reviewScaleFor(r.ReviewScore)
+	return true
+}
+
+// normalizeReviewScore converts a score on the given scale to the 10-point
+// scale.
+func normalizeReviewScore(score, scale float64) float64 {
+	if score <= 0 || scale <= 0 {
+		return 0
+	}
+	return roundTo(score/scale*ReviewScoreScale, 0.1)
+}
+
+func (r Rating) clean() Rating {
+	cleaned := Rating{
+		Stars:       roundTo(r.Stars, 0.5),
+		ReviewScore: roundTo(r.ReviewScore, 0.1),
+		ReviewCount: r.ReviewCount,
+	}
+	if r.HasUnscaledReviewScore() {
+		cleaned.ReviewScore = 0
+	}
+
+	if cleaned.Stars < 0 || cleaned.Stars > MaxStars {
+		cleaned.Stars = 0
+	}
+	if cleaned.ReviewScore < 0 || cleaned.ReviewScore > ReviewScoreScale {
+		cleaned.ReviewScore = 0
+		cleaned.ReviewCount = 0
+	}
+	if cleaned.ReviewCount < 0 {
+		cleaned.ReviewCount = 0
+	}
+
+	cleaned.ReviewScale = reviewScaleFor(cleaned.ReviewScore)
+	if !cleaned.IsZero() {
+		cleaned.samples = []Rating{cleaned}
+	}
+	return cleaned
+}
+
+func (r Rating) merge(other Rating) Rating {
+	r.samples = append(append([]Rating{}, r.samples...), other.samples...)
+	return r.Resolve(RatingMergeWeighted)
+}
+
+// Resolve recomputes the rating from every supplier sample merged into it.
+// "weighted" weighs review scores by review count, "average" treats suppliers
+// equally and "max" keeps the highest values.
+func (r Rating) Resolve(rule string) Rating {
+	if len(r.samples) == 0 {
+		return r
+	}
+
+	resolved := Rating{samples: r.samples}
+
+	var starSum, starCount float64
+	var scoreSum, scoreWeight float64
+	for _, sample := range r.samples {
+		if sample.Stars > 0 {
+			starSum += sample.Stars
+			starCount++
+			if sample.Stars > resolved.Stars && rule == RatingMergeMax {
+				resolved.Stars = sample.Stars
+			}
+		}
+
+		if sample.ReviewScore > 0 {
+			weight := 1.0
+			if rule == RatingMergeWeighted && sample.ReviewCount > 0 {
+				weight = float64(sample.ReviewCount)
+			}
+			scoreSum += sample.ReviewScore * weight
+			scoreWeight += weight
+			if sample.ReviewScore > resolved.ReviewScore && rule == RatingMergeMax {
+				resolved.ReviewScore = sample.ReviewScore
+			}
+		}
+
+		resolved.ReviewCount += sample.ReviewCount
+	}
+
+	if rule != RatingMergeMax {
+		if starCount > 0 {
+			resolved.Stars = roundTo(starSum/starCount, 0.5)
+		}
+		if scoreWeight > 0 {
+			resolved.ReviewScore = roundTo(scoreSum/scoreWeight, 0.1)
+		}
+	}
+	resolved.ReviewScale = reviewScaleFor(resolved.ReviewScore)
+
+	return resolved
+}
+
+func (h *Hotel) ResolveRating(rule string) {
+	h.Rating = h.Rating.Resolve(rule)
+}
+
+func IsValidRatingMergeRule(rule string) bool {
+	switch rule {
+	case RatingMergeWeighted, RatingMergeAverage, RatingMergeMax:
+		return true
+	}
+	return false
+}
+
+func reviewScaleFor(score float64) float64 {
+	if score == 0 {
+		return 0
+	}
+	return ReviewScoreScale
+}
+
+func roundTo(value, step float64) float64 {
+	return math.Round(value/step) / math.Round(1/step)
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRatingReviewScale(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		supplierScale float64
+		wantScore     float64
+		wantApplied   bool
+	}{
+		{"explicit scale out of 5", `{"review_score": 4.5, "review_scale": 5}`, 0, 9, true},
+		{"explicit scale wins over supplier", `{"review_score": 4.5, "review_scale": 10}`, 5, 4.5, true},
+		{"explicit scale out of 100", `{"review_score": 87, "review_scale": 100}`, 0, 8.7, true},
+		{"supplier scale out of 10", `{"review_score": 4.5}`, 10, 4.5, true},
+		{"supplier scale out of 5", `{"review_score": 4.5}`, 5, 9, true},
+		{"no scale anywhere", `{"review_score": 4.5}`, 0, 0, false},
+		{"no score", `{"stars": 4}`, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rating Rating
+			if err := json.Unmarshal([]byte(tt.json), &rating); err != nil {
+				t.Fatal(err)
+			}
+			if applied := rating.ApplyReviewScale(tt.supplierScale); applied != tt.wantApplied {
+				t.Errorf("ApplyReviewScale = %v, want %v", applied, tt.wantApplied)
+			}
+			cleaned := rating.clean()
+			if cleaned.ReviewScore != tt.wantScore {
+				t.Errorf("review score = %v, want %v", cleaned.ReviewScore, tt.wantScore)
+			}
+			if cleaned.ReviewScore > 0 && cleaned.ReviewScale != ReviewScoreScale {
+				t.Errorf("review scale = %v, want %v", cleaned.ReviewScale, ReviewScoreScale)
+			}
+		})
+	}
+}
+
+func TestRatingUnscaledScoreDroppedByClean(t *testing.T) {
+	var rating Rating
+	if err := json.Unmarshal([]byte(`{"review_score": 4.5, "review_count": 12}`), &rating); err != nil {
+		t.Fatal(err)
+	}
+
+	cleaned := rating.clean()
+	if cleaned.ReviewScore != 0 || cleaned.ReviewCount != 12 {
+		t.Errorf("got %+v, want the score dropped and the count kept", cleaned)
+	}
+}
+
+func TestStoredRatingNotRescaled(t *testing.T) {
+	var rating Rating
+	if err := json.Unmarshal([]byte(`{"review_score": 4.5, "review_scale": 5}`), &rating); err != nil {
+		t.Fatal(err)
+	}
+	rating = rating.clean()
+
+	data, err := json.Marshal(rating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Rating
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.ReviewScore != 9 {
+		t.Errorf("stored review score = %v, want 9", stored.ReviewScore)
+	}
+}
+
+func TestRatingResolve(t *testing.T) {
+	a := Rating{Stars: 4, ReviewScore: 8, ReviewScale: 10, ReviewCount: 300}.clean()
+	b := Rating{Stars: 5, ReviewScore: 6, ReviewScale: 10, ReviewCount: 100}.clean()
+	merged := a.merge(b)
+
+	tests := []struct {
+		rule      string
+		wantStars float64
+		wantScore float64
+	}{
+		{RatingMergeWeighted, 4.5, 7.5},
+		{RatingMergeAverage, 4.5, 7},
+		{RatingMergeMax, 5, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			resolved := merged.Resolve(tt.rule)
+			if resolved.Stars != tt.wantStars || resolved.ReviewScore != tt.wantScore || resolved.ReviewCount != 400 {
+				t.Errorf("got %+v, want stars %v score %v count 400", resolved, tt.wantStars, tt.wantScore)
+			}
+		})
+	}
+}
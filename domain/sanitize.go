@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+func SanitizeText(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	// Tags are stripped again after unescaping, so entity-encoded markup
+	// ("&lt;b&gt;") does not come out as tags.
+	s = stripHTMLTags(s)
+	s = stripHTMLTags(html.UnescapeString(s))
+	s = fixMojibake(s)
+	s = norm.NFC.String(s)
+	s = collapseWhitespace(s)
+
+	return s
+}
+
+// stripHTMLTags replaces tags with a space and drops the contents of script
+// and style elements. A '<' only opens a tag when followed by a letter, '/' or
+// '!', so text such as "Children < 12 stay free" is kept.
+func stripHTMLTags(s string) string {
+	if !strings.ContainsRune(s, '<') {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		if s[i] != '<' || i+1 == len(s) || !isTagStart(s[i+1]) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '>')
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		tag := s[i+1 : i+end]
+		i += end + 1
+		b.WriteByte(' ')
+
+		if name := tagName(tag); name == "script" || name == "style" {
+			closing := indexFold(s[i:], "</"+name)
+			if closing < 0 {
+				break
+			}
+			i += closing
+		}
+	}
+
+	return b.String()
+}
+
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// tagName returns the lower-cased name of an opening tag, or "" for closing
+// tags, comments and declarations.
+func tagName(tag string) string {
+	end := 0
+	for end < len(tag) && (unicode.IsLetter(rune(tag[end])) || unicode.IsDigit(rune(tag[end]))) {
+		end++
+	}
+	return strings.ToLower(tag[:end])
+}
+
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fixMojibake reverses UTF-8 text that was decoded as Latin-1 ("CafÃ©") or
+// Windows-1252 ("Itâ€™s") upstream.
+func fixMojibake(s string) string {
+	if !looksLikeMojibake(s) {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := mojibakeByte(r)
+		if !ok {
+			return s
+		}
+		buf = append(buf, b)
+	}
+
+	if !utf8.Valid(buf) {
+		return s
+	}
+
+	return string(buf)
+}
+
+func looksLikeMojibake(s string) bool {
+	var prev rune
+	for _, r := range s {
+		if b, ok := mojibakeByte(r); ok && prev >= 0xC2 && prev <= 0xF4 && b >= 0x80 && b <= 0xBF {
+			return true
+		}
+		prev = r
+	}
+	return false
+}
+
+// mojibakeByte returns the byte that decoded to r as Latin-1 or Windows-1252.
+func mojibakeByte(r rune) (byte, bool) {
+	if r <= 0xFF {
+		return byte(r), true
+	}
+	return charmap.Windows1252.EncodeRune(r)
+}
+
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	pendingSpace := false
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '\u200b' || r == '\ufeff' {
+			pendingSpace = true
+			continue
+		}
+		if unicode.IsControl(r) {
+			continue
+		}
+		if pendingSpace && b.Len() > 0 {
+			b.WriteRune(' ')
+		}
+		pendingSpace = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "testing"
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"plain", "Pool and gym", "Pool and gym"},
+		{"tags", "<p>Pool</p><br/>and <b>gym</b>", "Pool and gym"},
+		{"less than in text", "Children < 12 stay free", "Children < 12 stay free"},
+		{"less than before digit", "Rooms<5 and <b>bar</b>", "Rooms<5 and bar"},
+		{"unclosed tag", "Pool <b and gym", "Pool <b and gym"},
+		{"comment", "Pool<!-- draft -->gym", "Pool gym"},
+		{"script body", "Pool<script>alert('x')</script> and gym", "Pool and gym"},
+		{"style body", "<STYLE type=\"text/css\">p { color: red }</Style>Lobby", "Lobby"},
+		{"unclosed script", "Pool<script>alert('x')", "Pool"},
+		{"entities", "Caf&eacute; &amp; bar", "Café & bar"},
+		{"encoded tags", "&lt;b&gt;Pool&lt;/b&gt; and gym", "Pool and gym"},
+		{"encoded script", "Pool&lt;script&gt;alert('x')&lt;/script&gt;", "Pool"},
+		{"encoded less than", "Children &lt; 12 stay free", "Children < 12 stay free"},
+		{"mojibake", "CafÃ©", "Café"},
+		{"windows-1252 mojibake", "Itâ€™s a â€œgreatâ€ stay", "It’s a “great” stay"},
+		{"windows-1252 mojibake dash", "Pool â€“ gym", "Pool – gym"},
+		{"whitespace", "  Pool\n\t​and   gym ", "Pool and gym"},
+		{"control characters", "Pool\x00 gym", "Pool gym"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeText(tt.in); got != tt.want {
+				t.Errorf("SanitizeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
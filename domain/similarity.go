@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var phraseStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "is": true, "are": true, "be": true,
+	"at": true, "in": true, "on": true, "of": true, "to": true, "for": true,
+	"and": true, "with": true, "our": true, "will": true, "can": true,
+}
+
+var phraseReplacements = strings.NewReplacer(
+	"wi-fi", "wifi",
+	"wi fi", "wifi",
+	"check in", "checkin",
+	"check-in", "checkin",
+	"check out", "checkout",
+	"check-out", "checkout",
+	"&", " and ",
+	"n't", " not",
+	"n’t", " not",
+)
+
+// negationTokens flip the meaning of a phrase, so phrases that differ in them
+// are never collapsed however similar the rest is.
+var negationTokens = map[string]bool{
+	"no": true, "not": true, "non": true, "never": true, "without": true,
+}
+
+type CollapseDecision struct {
+	Field      string  `json:"field"`
+	Kept       string  `json:"kept"`
+	Dropped    string  `json:"dropped"`
+	Similarity float64 `json:"similarity"`
+}
+
+// CollapseNearDuplicates merges booking conditions and amenity phrases whose
+// token similarity reaches threshold, keeping the longest variant of each
+// group, and returns what was dropped so callers can audit it. Phrases with
+// different numbers or negations ("24 hours" and "48 hours", "allowed" and
+// "not allowed") are never merged.
+func (h *Hotel) CollapseNearDuplicates(threshold float64) []CollapseDecision {
+	var decisions []CollapseDecision
+
+	collapse := func(field string, phrases []string) []string {
+		kept, dropped := collapsePhrases(phrases, threshold)
+		for i := range dropped {
+			dropped[i].Field = field
+		}
+		decisions = append(decisions, dropped...)
+		return kept
+	}
+
+	h.BookingConditions = collapse("booking_conditions", h.BookingConditions)
+	h.Amenities.General = collapse("amenities.general", h.Amenities.General)
+	h.Amenities.Room = collapse("amenities.room", h.Amenities.Room)
+
+	for locale, content := range h.Translations {
+		content.Amenities.General = collapse(locale+".amenities.general", content.Amenities.General)
+		content.Amenities.Room = collapse(locale+".amenities.room", content.Amenities.Room)
+		h.Translations[locale] = content
+	}
+
+	if len(decisions) > 0 {
+		h.Policies = ExtractPolicies(h.BookingConditions)
+	}
+
+	return decisions
+}
+
+func collapsePhrases(phrases []string, threshold float64) ([]string, []CollapseDecision) {
+	if len(phrases) < 2 {
+		return phrases, nil
+	}
+
+	ordered := append([]string{}, phrases...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return len(ordered[i]) > len(ordered[j])
+	})
+
+	type group struct {
+		phrase string
+		tokens map[string]bool
+		guard  string
+	}
+
+	var groups []group
+	var decisions []CollapseDecision
+
+	for _, phrase := range ordered {
+		tokens := phraseTokens(phrase)
+		guard := phraseGuard(tokens)
+
+		bestIndex, bestScore := -1, 0.0
+		for i, g := range groups {
+			if g.guard != guard {
+				continue
+			}
+			if score := tokenSimilarity(tokens, g.tokens); score > bestScore {
+				bestIndex, bestScore = i, score
+			}
+		}
+
+		if bestIndex >= 0 && bestScore >= threshold {
+			decisions = append(decisions, CollapseDecision{
+				Kept:       groups[bestIndex].phrase,
+				Dropped:    phrase,
+				Similarity: bestScore,
+			})
+			continue
+		}
+
+		groups = append(groups, group{phrase: phrase, tokens: tokens, guard: guard})
+	}
+
+	result := make([]string, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, g.phrase)
+	}
+	sort.Strings(result)
+
+	return result, decisions
+}
+
+func phraseTokens(phrase string) map[string]bool {
+	text := phraseReplacements.Replace(strings.ToLower(SanitizeText(phrase)))
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !phraseStopwords[field] {
+			tokens[field] = true
+		}
+	}
+	return tokens
+}
+
+// phraseGuard lists the numeric and negation tokens of a phrase; only phrases
+// with the same guard may be collapsed.
+func phraseGuard(tokens map[string]bool) string {
+	var guard []string
+	for token := range tokens {
+		if negationTokens[token] || strings.IndexFunc(token, unicode.IsDigit) >= 0 {
+			guard = append(guard, token)
+		}
+	}
+	sort.Strings(guard)
+	return strings.Join(guard, " ")
+}
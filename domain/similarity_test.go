@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCollapsePhrases(t *testing.T) {
+	const threshold = 0.85
+
+	tests := []struct {
+		name    string
+		phrases []string
+		want    string
+	}{
+		{"single phrase", []string{"Free WiFi"}, "[Free WiFi]"},
+		{"spelling variants, first of equal length kept",
+			[]string{"Free Wi-Fi in all rooms", "Free WiFi in all rooms!"},
+			"[Free Wi-Fi in all rooms]"},
+		{"check-in variants",
+			[]string{"Check-in from 3pm", "Check in from 3pm"},
+			"[Check-in from 3pm]"},
+		{"different cancellation windows",
+			[]string{"Free cancellation up to 24 hours before arrival", "Free cancellation up to 48 hours before arrival"},
+			"[Free cancellation up to 24 hours before arrival Free cancellation up to 48 hours before arrival]"},
+		{"different amounts",
+			[]string{"A deposit of SGD 100 is required at check-in", "A deposit of SGD 150 is required at check-in"},
+			"[A deposit of SGD 100 is required at check-in A deposit of SGD 150 is required at check-in]"},
+		{"negated rule",
+			[]string{"Smoking is not allowed in rooms", "Smoking is allowed in rooms"},
+			"[Smoking is allowed in rooms Smoking is not allowed in rooms]"},
+		{"contracted negation",
+			[]string{"Pets aren't allowed in the rooms", "Pets are allowed in the rooms"},
+			"[Pets are allowed in the rooms Pets aren't allowed in the rooms]"},
+		{"non- prefix",
+			[]string{"Non-smoking rooms available on request", "Smoking rooms available on request"},
+			"[Non-smoking rooms available on request Smoking rooms available on request]"},
+		{"same negation collapses",
+			[]string{"Smoking is not allowed in any rooms", "Smoking is not allowed in rooms"},
+			"[Smoking is not allowed in any rooms]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, _ := collapsePhrases(tt.phrases, threshold)
+			if got := fmt.Sprint(kept); got != tt.want {
+				t.Errorf("collapsePhrases(%q) = %s, want %s", tt.phrases, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapseNearDuplicates(t *testing.T) {
+	hotel := &Hotel{
+		BookingConditions: []string{
+			"Free cancellation up to 24 hours before arrival",
+			"Free cancellation up to 24 hours before arrival.",
+			"Free cancellation up to 48 hours before arrival",
+		},
+		Amenities: Amenities{General: []string{"Outdoor pool", "outdoor pool"}},
+	}
+
+	decisions := hotel.CollapseNearDuplicates(0.85)
+
+	if len(hotel.BookingConditions) != 2 || len(hotel.Amenities.General) != 1 {
+		t.Errorf("kept %q and %q", hotel.BookingConditions, hotel.Amenities.General)
+	}
+	if len(decisions) != 2 || decisions[0].Field != "booking_conditions" || decisions[1].Field != "amenities.general" {
+		t.Errorf("decisions = %+v", decisions)
+	}
+	if hotel.Policies.FreeCancellationHours != 24 {
+		t.Errorf("policies not extracted from the kept conditions: %+v", hotel.Policies)
+	}
+}
@@ -0,0 +1,223 @@
+package httpinterface
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"hotelsdatapipeline/domain"
+)
+
+type DuplicateDecisionRequest struct {
+	HotelID     string `json:"hotel_id"`
+	DuplicateID string `json:"duplicate_id"`
+}
+
+func (h *HTTPHandler) GetDuplicateCandidates(w http.ResponseWriter, r *http.Request) {
+	candidates, err := h.repository.GetDuplicateCandidates(r.Context())
+	if err != nil {
+		log.Printf("Failed to get duplicate candidates: %v", err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to get duplicate candidates",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    candidates,
+		Count:   len(candidates),
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) ApproveDuplicate(w http.ResponseWriter, r *http.Request) {
+	decision, ok := h.decodeDuplicateDecision(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.ApproveDuplicate(r.Context(), decision.HotelID, decision.DuplicateID); err != nil {
+		log.Printf("Failed to approve duplicate %s -> %s: %v", decision.DuplicateID, decision.HotelID, err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to approve duplicate",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    decision,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) RejectDuplicate(w http.ResponseWriter, r *http.Request) {
+	decision, ok := h.decodeDuplicateDecision(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.RejectDuplicate(r.Context(), decision.HotelID, decision.DuplicateID); err != nil {
+		log.Printf("Failed to reject duplicate %s / %s: %v", decision.HotelID, decision.DuplicateID, err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to reject duplicate",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    decision,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) decodeDuplicateDecision(w http.ResponseWriter, r *http.Request) (DuplicateDecisionRequest, bool) {
+	var decision DuplicateDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return decision, false
+	}
+
+	decision.HotelID = strings.TrimSpace(decision.HotelID)
+	decision.DuplicateID = strings.TrimSpace(decision.DuplicateID)
+
+	if decision.HotelID == "" || decision.DuplicateID == "" || decision.HotelID == decision.DuplicateID {
+		response := APIResponse{
+			Success: false,
+			Error:   "hotel_id and duplicate_id are required and must differ",
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return decision, false
+	}
+
+	return decision, true
+}
+
+func (h *HTTPHandler) GetCrosswalkEntries(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.repository.GetCrosswalkEntries(r.Context())
+	if err != nil {
+		log.Printf("Failed to get crosswalk entries: %v", err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to get crosswalk entries",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    entries,
+		Count:   len(entries),
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) StoreCrosswalkEntry(w http.ResponseWriter, r *http.Request) {
+	var entry domain.CrosswalkEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	entry, err := entry.Normalize()
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.repository.StoreCrosswalkEntry(r.Context(), entry); err != nil {
+		log.Printf("Failed to store crosswalk entry %+v: %v", entry, err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to store crosswalk entry",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    entry,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) DeleteCrosswalkEntry(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	supplier := strings.ToLower(strings.TrimSpace(query.Get("supplier")))
+	kind := strings.ToLower(strings.TrimSpace(query.Get("kind")))
+	nativeID := strings.TrimSpace(query.Get("native_id"))
+
+	if supplier == "" || kind == "" || nativeID == "" {
+		response := APIResponse{
+			Success: false,
+			Error:   "supplier, kind and native_id parameters are required",
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.repository.DeleteCrosswalkEntry(r.Context(), supplier, kind, nativeID); err != nil {
+		log.Printf("Failed to delete crosswalk entry %s/%s/%s: %v", supplier, kind, nativeID, err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to delete crosswalk entry",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) GetCrosswalkReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.repository.GetCrosswalkReport(r.Context())
+	if err != nil {
+		log.Printf("Failed to get crosswalk report: %v", err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to get unmapped IDs",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    report,
+		Count:   len(report.Unmapped),
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
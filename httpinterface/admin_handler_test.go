@@ -0,0 +1,106 @@
+package httpinterface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hotelsdatapipeline/domain"
+	"hotelsdatapipeline/infra"
+)
+
+func newTestRouter(t *testing.T, adminToken string) (*Router, *infra.MemoryRepository) {
+	t.Helper()
+
+	repository, err := infra.NewMemoryRepository("", infra.TTLPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRouter(repository, domain.LocaleSettings{}, adminToken), repository
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+		want          int
+	}{
+		{"disabled", "", "Bearer ", http.StatusForbidden},
+		{"missing token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer nope", http.StatusUnauthorized},
+		{"valid token", "secret", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestRouter(t, tt.adminToken)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/duplicates", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAdminPreflightNeedsNoToken(t *testing.T) {
+	router, _ := newTestRouter(t, "secret")
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/admin/duplicates", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestApproveDuplicateRemovesDuplicate(t *testing.T) {
+	router, repository := newTestRouter(t, "secret")
+	ctx := context.Background()
+
+	hotels := []*domain.Hotel{
+		{HotelID: "keep", DestinationID: 1, HotelName: "Beach Villa"},
+		{HotelID: "dup", DestinationID: 1, HotelName: "Beach Villa"},
+	}
+	if err := domain.BatchError(repository.StoreHotels(ctx, hotels)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/duplicates/approve",
+		strings.NewReader(`{"hotel_id":"keep","duplicate_id":"dup"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve: got status %d: %s", rec.Code, rec.Body)
+	}
+
+	stored, total, err := repository.GetHotelsByDestinationID(ctx, 1, domain.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || stored[0].HotelID != "keep" {
+		t.Errorf("destination still lists %d hotels: %v", total, stored)
+	}
+	if hotel, _ := repository.GetHotelByID(ctx, "dup"); hotel != nil {
+		t.Errorf("duplicate still stored: %+v", hotel)
+	}
+
+	approved, err := repository.GetApprovedDuplicates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved["dup"] != "keep" {
+		t.Errorf("approved duplicates = %v", approved)
+	}
+}
@@ -0,0 +1,214 @@
+package httpinterface
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotelsdatapipeline/domain"
+
+	"github.com/gorilla/mux"
+)
+
+type HotelDiff struct {
+	HotelID     string               `json:"hotel_id"`
+	FromVersion int                  `json:"from_version"`
+	ToVersion   int                  `json:"to_version"`
+	Changes     []domain.FieldChange `json:"changes"`
+}
+
+func (h *HTTPHandler) GetHotelHistory(w http.ResponseWriter, r *http.Request) {
+	hotelID := mux.Vars(r)["id"]
+
+	history, ok := h.loadHotelHistory(w, r, hotelID)
+	if !ok {
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    history,
+		Count:   len(history),
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) GetHotelDiff(w http.ResponseWriter, r *http.Request) {
+	hotelID := mux.Vars(r)["id"]
+
+	history, ok := h.loadHotelHistory(w, r, hotelID)
+	if !ok {
+		return
+	}
+
+	// Version 0 stands for an empty hotel, so a hotel with a single retained
+	// version diffs as all of its fields added.
+	toVersion := history[0].Version
+	fromVersion := 0
+	if len(history) > 1 {
+		fromVersion = history[1].Version
+	}
+
+	query := r.URL.Query()
+	for param, target := range map[string]*int{"from": &fromVersion, "to": &toVersion} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			response := APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Invalid %s version: %s", param, value),
+			}
+			h.writeJSONResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		*target = version
+	}
+
+	from := &domain.HotelVersion{Hotel: &domain.Hotel{}}
+	if fromVersion != 0 {
+		var err error
+		from, err = domain.FindHotelVersion(history, fromVersion)
+		if err != nil {
+			response := APIResponse{
+				Success: false,
+				Error:   fmt.Sprintf("From %v", err),
+			}
+			h.writeJSONResponse(w, http.StatusNotFound, response)
+			return
+		}
+	}
+
+	to, err := domain.FindHotelVersion(history, toVersion)
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("To %v", err),
+		}
+		h.writeJSONResponse(w, http.StatusNotFound, response)
+		return
+	}
+
+	changes, err := domain.DiffHotels(from.Hotel, to.Hotel)
+	if err != nil {
+		log.Printf("Failed to diff hotel %s versions %d and %d: %v", hotelID, fromVersion, toVersion, err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to diff hotel versions",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data: HotelDiff{
+			HotelID:     hotelID,
+			FromVersion: fromVersion,
+			ToVersion:   toVersion,
+			Changes:     changes,
+		},
+		Count: len(changes),
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) loadHotelHistory(w http.ResponseWriter, r *http.Request, hotelID string) ([]domain.HotelVersion, bool) {
+	history, err := h.repository.GetHotelHistory(r.Context(), hotelID)
+	if err != nil {
+		log.Printf("Failed to get history of hotel %s: %v", hotelID, err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to get hotel history",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return nil, false
+	}
+
+	if len(history) == 0 {
+		response := APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("No history for hotel: %s", hotelID),
+		}
+		h.writeJSONResponse(w, http.StatusNotFound, response)
+		return nil, false
+	}
+
+	return history, true
+}
+
+func (h *HTTPHandler) parseAsOf(w http.ResponseWriter, r *http.Request) (*time.Time, bool) {
+	value := r.URL.Query().Get("as_of")
+	if value == "" {
+		return nil, true
+	}
+
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid as_of, expected RFC3339: %s", value),
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return nil, false
+	}
+
+	return &asOf, true
+}
+
+func (h *HTTPHandler) hotelAsOf(ctx context.Context, hotelID string, asOf time.Time) (*domain.Hotel, error) {
+	history, err := h.repository.GetHotelHistory(ctx, hotelID)
+	if err != nil {
+		return nil, err
+	}
+
+	hotel, ok := domain.HotelAsOf(history, asOf)
+	if !ok {
+		return nil, fmt.Errorf("hotel %s did not exist at %s", hotelID, asOf.Format(time.RFC3339))
+	}
+	return hotel, nil
+}
+
+func (h *HTTPHandler) hotelsByIDRangeAsOf(ctx context.Context, hotelIDs []string, asOf time.Time) ([]*domain.Hotel, error) {
+	var hotels []*domain.Hotel
+	for _, hotelID := range hotelIDs {
+		history, err := h.repository.GetHotelHistory(ctx, hotelID)
+		if err != nil {
+			return nil, err
+		}
+		if hotel, ok := domain.HotelAsOf(history, asOf); ok {
+			hotels = append(hotels, hotel)
+		}
+	}
+	return hotels, nil
+}
+
+// hotelsByDestinationAsOf reads the history of every hotel ever recorded in
+// the destination, since hotels may have belonged to a different destination
+// at asOf than they do now.
+func (h *HTTPHandler) hotelsByDestinationAsOf(ctx context.Context, destinationID int, asOf time.Time) ([]*domain.Hotel, error) {
+	hotelIDs, err := h.repository.GetDestinationHistoryHotelIDs(ctx, destinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	hotels, err := h.hotelsByIDRangeAsOf(ctx, hotelIDs, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*domain.Hotel{}
+	for _, hotel := range hotels {
+		if hotel.DestinationID == destinationID {
+			result = append(result, hotel)
+		}
+	}
+	return result, nil
+}
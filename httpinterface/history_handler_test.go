@@ -0,0 +1,77 @@
+package httpinterface
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+func TestGetHotelDiff(t *testing.T) {
+	router, repository := newTestRouter(t, "")
+	ctx := context.Background()
+
+	record := func(hotelID, name string, maxVersions int) {
+		t.Helper()
+		version := domain.HotelVersion{
+			RunID:       name,
+			RecordedAt:  time.Now(),
+			Fingerprint: name,
+			Hotel:       &domain.Hotel{HotelID: hotelID, DestinationID: 1, HotelName: name},
+		}
+		if _, err := repository.AppendHotelVersion(ctx, version, maxVersions); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record("single", "Grand", 0)
+	record("pruned", "Grand", 1)
+	record("pruned", "Grand Hotel", 1)
+	record("both", "Grand", 0)
+	record("both", "Grand Hotel", 0)
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/api/v1/hotels/single/diff", http.StatusOK, "0 1 [destination_id hotel_id hotel_name]"},
+		{"/api/v1/hotels/pruned/diff", http.StatusOK, "0 2 [destination_id hotel_id hotel_name]"},
+		{"/api/v1/hotels/both/diff", http.StatusOK, "1 2 [hotel_name]"},
+		{"/api/v1/hotels/both/diff?from=0&to=1", http.StatusOK, "0 1 [destination_id hotel_id hotel_name]"},
+		{"/api/v1/hotels/pruned/diff?from=1", http.StatusNotFound, ""},
+		{"/api/v1/hotels/both/diff?from=x", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data HotelDiff `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, change := range response.Data.Changes {
+				paths = append(paths, change.Path)
+			}
+			got := fmt.Sprint(response.Data.FromVersion, response.Data.ToVersion, paths)
+			if got != tt.want {
+				t.Errorf("diff = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
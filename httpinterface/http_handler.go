@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,7 +15,8 @@ import (
 )
 
 type HTTPHandler struct {
-	repository domain.HotelRepository
+	repository domain.Repository
+	locales    domain.LocaleSettings
 }
 
 type APIResponse struct {
@@ -22,11 +24,13 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Count   int         `json:"count,omitempty"`
+	Total   int         `json:"total,omitempty"`
 }
 
-func NewHTTPHandler(repository domain.HotelRepository) *HTTPHandler {
+func NewHTTPHandler(repository domain.Repository, locales domain.LocaleSettings) *HTTPHandler {
 	return &HTTPHandler{
 		repository: repository,
+		locales:    locales,
 	}
 }
 
@@ -52,7 +56,18 @@ func (h *HTTPHandler) GetHotelByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hotel, err := h.repository.GetHotelByID(hotelID)
+	asOf, ok := h.parseAsOf(w, r)
+	if !ok {
+		return
+	}
+
+	var hotel *domain.Hotel
+	var err error
+	if asOf != nil {
+		hotel, err = h.hotelAsOf(r.Context(), hotelID, *asOf)
+	} else {
+		hotel, err = h.repository.GetHotelByID(r.Context(), hotelID)
+	}
 	if err != nil {
 		log.Printf("Failed to get hotel %s: %v", hotelID, err)
 		response := APIResponse{
@@ -63,6 +78,8 @@ func (h *HTTPHandler) GetHotelByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	hotel = h.presentHotel(w, r, hotel)
+
 	response := APIResponse{
 		Success: true,
 		Data:    hotel,
@@ -86,7 +103,49 @@ func (h *HTTPHandler) GetHotelsByDestination(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	hotels, err := h.repository.GetHotelsByDestinationID(destinationID)
+	asOf, ok := h.parseAsOf(w, r)
+	if !ok {
+		return
+	}
+
+	query, err := parseHotelQuery(r)
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	page, err := parsePage(r)
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// Without filters or sorting the repository can page the index itself;
+	// otherwise the whole destination is filtered before paging.
+	var hotels []*domain.Hotel
+	var total int
+	if asOf == nil && query == (domain.HotelQuery{}) {
+		hotels, total, err = h.repository.GetHotelsByDestinationID(r.Context(), destinationID, page)
+	} else {
+		if asOf != nil {
+			hotels, err = h.hotelsByDestinationAsOf(r.Context(), destinationID, *asOf)
+		} else {
+			hotels, _, err = h.repository.GetHotelsByDestinationID(r.Context(), destinationID, domain.Page{})
+		}
+		if err == nil {
+			hotels = query.Apply(hotels)
+			total = len(hotels)
+			hotels = domain.PageHotels(hotels, page)
+		}
+	}
 	if err != nil {
 		log.Printf("Failed to get hotels for destination %d: %v", destinationID, err)
 		response := APIResponse{
@@ -97,10 +156,13 @@ func (h *HTTPHandler) GetHotelsByDestination(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	hotels = h.presentHotels(w, r, hotels)
+
 	response := APIResponse{
 		Success: true,
 		Data:    hotels,
 		Count:   len(hotels),
+		Total:   total,
 	}
 
 	h.writeJSONResponse(w, http.StatusOK, response)
@@ -145,7 +207,18 @@ func (h *HTTPHandler) GetHotelsByIDRange(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hotels, err := h.repository.GetHotelsByIDRange(cleanHotelIDs)
+	asOf, ok := h.parseAsOf(w, r)
+	if !ok {
+		return
+	}
+
+	var hotels []*domain.Hotel
+	var err error
+	if asOf != nil {
+		hotels, err = h.hotelsByIDRangeAsOf(r.Context(), cleanHotelIDs, *asOf)
+	} else {
+		hotels, err = h.repository.GetHotelsByIDRange(r.Context(), cleanHotelIDs)
+	}
 	if err != nil {
 		log.Printf("Failed to get hotels by ID range: %v", err)
 		response := APIResponse{
@@ -156,6 +229,18 @@ func (h *HTTPHandler) GetHotelsByIDRange(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	query, err := parseHotelQuery(r)
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	hotels = h.presentHotels(w, r, query.Apply(hotels))
+
 	response := APIResponse{
 		Success: true,
 		Data:    hotels,
@@ -165,6 +250,232 @@ func (h *HTTPHandler) GetHotelsByIDRange(w http.ResponseWriter, r *http.Request)
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+func (h *HTTPHandler) GetDestinations(w http.ResponseWriter, r *http.Request) {
+	destinations, err := h.repository.GetAllDestinations(r.Context())
+	if err != nil {
+		log.Printf("Failed to get destinations: %v", err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Failed to get destinations",
+		}
+		h.writeJSONResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    destinations,
+		Count:   len(destinations),
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) GetDestinationByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	destinationIDStr := vars["id"]
+
+	destinationID, err := strconv.Atoi(destinationIDStr)
+	if err != nil {
+		response := APIResponse{
+			Success: false,
+			Error:   "Invalid destination ID",
+		}
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	destination, err := h.repository.GetDestinationByID(r.Context(), destinationID)
+	if err != nil {
+		log.Printf("Failed to get destination %d: %v", destinationID, err)
+		response := APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Destination not found: %d", destinationID),
+		}
+		h.writeJSONResponse(w, http.StatusNotFound, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    destination,
+		Count:   1,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) GetQualityDistribution(w http.ResponseWriter, r *http.Request) {
+	report, err := h.repository.GetQualityReport(r.Context())
+	if err != nil {
+		log.Printf("Failed to get quality report: %v", err)
+		response := APIResponse{
+			Success: false,
+			Error:   "Quality distribution not available",
+		}
+		h.writeJSONResponse(w, http.StatusNotFound, response)
+		return
+	}
+
+	response := APIResponse{
+		Success: true,
+		Data:    report,
+		Count:   report.Count,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+func (h *HTTPHandler) presentHotel(w http.ResponseWriter, r *http.Request, hotel *domain.Hotel) *domain.Hotel {
+	return h.presentHotels(w, r, []*domain.Hotel{hotel})[0]
+}
+
+// presentHotels localizes hotels and sets Content-Language to the locales
+// served, which vary with Accept-Language.
+func (h *HTTPHandler) presentHotels(w http.ResponseWriter, r *http.Request, hotels []*domain.Hotel) []*domain.Hotel {
+	chain := h.locales.Chain(requestedLocales(r))
+	hide := hideDeadImages(r)
+
+	result := make([]*domain.Hotel, 0, len(hotels))
+	var locales []string
+	for _, hotel := range hotels {
+		hotel = hotel.Localize(chain)
+		if hide {
+			hotel = hotel.WithoutDeadImages()
+		}
+		if hotel.Locale != "" && !containsLocale(locales, hotel.Locale) {
+			locales = append(locales, hotel.Locale)
+		}
+		result = append(result, hotel)
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+	if len(locales) > 0 {
+		w.Header().Set("Content-Language", strings.Join(locales, ", "))
+	}
+	return result
+}
+
+func containsLocale(locales []string, locale string) bool {
+	for _, l := range locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHotelQuery(r *http.Request) (domain.HotelQuery, error) {
+	var query domain.HotelQuery
+	params := r.URL.Query()
+
+	if value := params.Get("min_stars"); value != "" {
+		minStars, err := strconv.ParseFloat(value, 64)
+		if err != nil || minStars < 0 || minStars > domain.MaxStars {
+			return query, fmt.Errorf("Invalid min_stars: %s", value)
+		}
+		query.MinStars = minStars
+	}
+
+	if value := params.Get("min_review_score"); value != "" {
+		minScore, err := strconv.ParseFloat(value, 64)
+		if err != nil || minScore < 0 || minScore > domain.ReviewScoreScale {
+			return query, fmt.Errorf("Invalid min_review_score: %s", value)
+		}
+		query.MinReviewScore = minScore
+	}
+
+	if value := params.Get("min_quality"); value != "" {
+		minQuality, err := strconv.ParseFloat(value, 64)
+		if err != nil || minQuality < 0 || minQuality > 100 {
+			return query, fmt.Errorf("Invalid min_quality: %s", value)
+		}
+		query.MinQuality = minQuality
+	}
+
+	sortBy, descending, err := domain.ParseHotelSort(params.Get("sort"))
+	if err != nil {
+		return query, fmt.Errorf("Invalid sort: %v", err)
+	}
+	query.SortBy = sortBy
+	query.Descending = descending
+
+	return query, nil
+}
+
+func parsePage(r *http.Request) (domain.Page, error) {
+	var page domain.Page
+	params := r.URL.Query()
+
+	if value := params.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return page, fmt.Errorf("Invalid offset: %s", value)
+		}
+		page.Offset = offset
+	}
+
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return page, fmt.Errorf("Invalid limit: %s", value)
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+func hideDeadImages(r *http.Request) bool {
+	hide, _ := strconv.ParseBool(r.URL.Query().Get("hide_dead_images"))
+	return hide
+}
+
+// requestedLocales returns ?lang= if present, otherwise the Accept-Language
+// entries ordered by quality.
+func requestedLocales(r *http.Request) []string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return strings.Split(lang, ",")
+	}
+
+	type weighted struct {
+		locale  string
+		quality float64
+	}
+
+	var entries []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := strings.TrimSpace(fields[0])
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			entries = append(entries, weighted{locale: locale, quality: quality})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].quality > entries[j].quality
+	})
+
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.locale)
+	}
+	return locales
+}
+
 func (h *HTTPHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, response APIResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,37 @@
+package httpinterface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hotelsdatapipeline/domain"
+)
+
+func TestHotelResponseLanguageHeaders(t *testing.T) {
+	router, repository := newTestRouter(t, "")
+
+	hotel := &domain.Hotel{HotelID: "h", DestinationID: 1, HotelName: "Villa de la Plage", Language: "fr"}
+	hotel.CleanData()
+	if err := domain.BatchError(repository.StoreHotels(context.Background(), []*domain.Hotel{hotel})); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/api/v1/hotels/h", "/api/v1/hotels/destination/1"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Language", "fr-CA")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d: %s", path, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Language"); got != "fr" {
+			t.Errorf("%s: Content-Language = %q, want fr", path, got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Language" {
+			t.Errorf("%s: Vary = %q, want Accept-Language", path, got)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package httpinterface
 
 import (
+	"crypto/subtle"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"hotelsdatapipeline/domain"
@@ -12,18 +14,23 @@ import (
 
 type Router struct {
 	router     *mux.Router
-	repository domain.HotelRepository
+	repository domain.Repository
 	handler    *HTTPHandler
+	adminToken string
 }
 
-func NewRouter(repository domain.HotelRepository) *Router {
+func NewRouter(repository domain.Repository, locales domain.LocaleSettings, adminToken string) *Router {
 	router := mux.NewRouter()
-	handler := NewHTTPHandler(repository)
+	handler := NewHTTPHandler(repository, locales)
 
 	r := &Router{
 		router:     router,
 		repository: repository,
 		handler:    handler,
+		adminToken: adminToken,
+	}
+	if adminToken == "" {
+		log.Println("No admin token configured, admin endpoints are disabled")
 	}
 
 	r.setupMiddleware()
@@ -44,8 +51,27 @@ func (r *Router) setupRoutes() {
 
 	api.HandleFunc("/hotels/range", r.handler.GetHotelsByIDRange).Methods("GET")
 	api.HandleFunc("/hotels/destination/{id}", r.handler.GetHotelsByDestination).Methods("GET")
+	api.HandleFunc("/hotels/{id}/history", r.handler.GetHotelHistory).Methods("GET")
+	api.HandleFunc("/hotels/{id}/diff", r.handler.GetHotelDiff).Methods("GET")
 	api.HandleFunc("/hotels/{id}", r.handler.GetHotelByID).Methods("GET")
 
+	api.HandleFunc("/destinations", r.handler.GetDestinations).Methods("GET")
+	api.HandleFunc("/destinations/{id}", r.handler.GetDestinationByID).Methods("GET")
+
+	api.HandleFunc("/quality/distribution", r.handler.GetQualityDistribution).Methods("GET")
+
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(r.adminAuthMiddleware)
+
+	admin.HandleFunc("/duplicates", r.handler.GetDuplicateCandidates).Methods("GET")
+	admin.HandleFunc("/duplicates/approve", r.handler.ApproveDuplicate).Methods("POST")
+	admin.HandleFunc("/duplicates/reject", r.handler.RejectDuplicate).Methods("POST")
+
+	admin.HandleFunc("/crosswalk", r.handler.GetCrosswalkEntries).Methods("GET")
+	admin.HandleFunc("/crosswalk", r.handler.StoreCrosswalkEntry).Methods("PUT")
+	admin.HandleFunc("/crosswalk", r.handler.DeleteCrosswalkEntry).Methods("DELETE")
+	admin.HandleFunc("/crosswalk/unmapped", r.handler.GetCrosswalkReport).Methods("GET")
+
 	api.Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -67,11 +93,36 @@ func (r *Router) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// adminAuthMiddleware requires "Authorization: Bearer <admin token>".
+func (r *Router) adminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.adminToken == "" {
+			r.handler.writeJSONResponse(w, http.StatusForbidden, APIResponse{
+				Success: false,
+				Error:   "Admin endpoints are disabled",
+			})
+			return
+		}
+
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(r.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			r.handler.writeJSONResponse(w, http.StatusUnauthorized, APIResponse{
+				Success: false,
+				Error:   "Invalid or missing admin token",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
 func (r *Router) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language")
 
 		if req.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,245 @@
+package infra
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"hotelsdatapipeline/domain"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Stored records are JSON envelopes (see schema.go), or the same envelope in
+// MessagePack. The other encodings mark the record with a leading header byte,
+// which JSON never starts with, so records in any encoding can be read while a
+// new one is rolled out. JSON records have no header and stay readable by older
+// versions.
+const (
+	EncodingJSON     = "json"
+	EncodingMsgpack  = "msgpack"
+	EncodingJSONGzip = "json+gzip"
+	EncodingJSONZstd = "json+zstd"
+)
+
+var Encodings = []string{EncodingJSON, EncodingMsgpack, EncodingJSONGzip, EncodingJSONZstd}
+
+// encodingMsgpackJSON is reported for MessagePack records converted from the
+// JSON record, as written by older versions, so that they are rewritten.
+const encodingMsgpackJSON = "msgpack (converted from json)"
+
+const (
+	headerMsgpackJSON byte = 0x01
+	headerJSONGzip    byte = 0x02
+	headerJSONZstd    byte = 0x03
+	headerMsgpack     byte = 0x04
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// msgpackEnvelope is recordEnvelope in MessagePack. Data is encoded from the
+// value itself, so integers keep their full precision.
+type msgpackEnvelope struct {
+	SchemaVersion int         `msgpack:"schema_version"`
+	Data          interface{} `msgpack:"data"`
+}
+
+// encodeAs encodes value as a stored record in encoding.
+func encodeAs(encoding string, value interface{}) ([]byte, error) {
+	if encoding == EncodingMsgpack {
+		var buf bytes.Buffer
+		buf.WriteByte(headerMsgpack)
+		encoder := msgpack.NewEncoder(&buf)
+		// Fields are named as in JSON, so a record migrated through JSON
+		// reads the same.
+		encoder.SetCustomStructTag("json")
+		encoder.UseCompactInts(true)
+		if err := encoder.Encode(msgpackEnvelope{SchemaVersion: HotelSchemaVersion, Data: value}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	record, err := encodeRecord(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case EncodingJSON:
+		return record, nil
+	case EncodingJSONGzip:
+		var buf bytes.Buffer
+		buf.WriteByte(headerJSONGzip)
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(record); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case EncodingJSONZstd:
+		return zstdEncoder.EncodeAll(record, []byte{headerJSONZstd}), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", encoding)
+	}
+}
+
+// decodeAs reads a stored record in any encoding into target, migrating it as
+// decodeRecord does. It reports whether a migration was applied and the
+// encoding the record was stored in.
+func decodeAs(data []byte, target interface{}, hotels func(payload interface{}) []interface{}) (bool, string, error) {
+	if len(data) > 0 && data[0] == headerMsgpack {
+		migrated, err := decodeMsgpack(data[1:], target, hotels)
+		return migrated, EncodingMsgpack, err
+	}
+
+	record, encoding, err := decodeStored(data)
+	if err != nil {
+		return false, "", err
+	}
+	migrated, err := decodeRecord(record, target, hotels)
+	return migrated, encoding, err
+}
+
+// decodeMsgpack reads a MessagePack envelope into target. Records of an older
+// schema version are converted to JSON, since migrations work on JSON.
+func decodeMsgpack(data []byte, target interface{}, hotels func(payload interface{}) []interface{}) (bool, error) {
+	var envelope struct {
+		SchemaVersion int                `msgpack:"schema_version"`
+		Data          msgpack.RawMessage `msgpack:"data"`
+	}
+	if err := msgpack.Unmarshal(data, &envelope); err != nil {
+		return false, fmt.Errorf("failed to unpack record: %w", err)
+	}
+	if envelope.SchemaVersion > HotelSchemaVersion {
+		return false, fmt.Errorf("record schema version %d is newer than supported version %d", envelope.SchemaVersion, HotelSchemaVersion)
+	}
+
+	decoder := msgpack.NewDecoder(bytes.NewReader(envelope.Data))
+	decoder.SetCustomStructTag("json")
+	// Numbers in generic values are read as int64, uint64 or float64,
+	// whatever size they were packed in.
+	decoder.UseLooseInterfaceDecoding(true)
+
+	if envelope.SchemaVersion == HotelSchemaVersion {
+		if err := decoder.Decode(target); err != nil {
+			return false, fmt.Errorf("failed to unpack record: %w", err)
+		}
+		return false, nil
+	}
+
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return false, fmt.Errorf("failed to unpack record: %w", err)
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	record, err := json.Marshal(recordEnvelope{SchemaVersion: envelope.SchemaVersion, Data: payloadJSON})
+	if err != nil {
+		return false, err
+	}
+	return decodeRecord(record, target, hotels)
+}
+
+// decodeStored returns the JSON record held in data and the encoding it was
+// stored in. MessagePack records are read by decodeAs.
+func decodeStored(data []byte) ([]byte, string, error) {
+	if len(data) == 0 {
+		return data, EncodingJSON, nil
+	}
+
+	switch data[0] {
+	case headerMsgpackJSON:
+		var value interface{}
+		if err := msgpack.Unmarshal(data[1:], &value); err != nil {
+			return nil, "", fmt.Errorf("failed to unpack record: %w", err)
+		}
+		record, err := json.Marshal(value)
+		if err != nil {
+			return nil, "", err
+		}
+		return record, encodingMsgpackJSON, nil
+	case headerJSONGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decompress record: %w", err)
+		}
+		defer reader.Close()
+		record, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decompress record: %w", err)
+		}
+		return record, EncodingJSONGzip, nil
+	case headerJSONZstd:
+		record, err := zstdDecoder.DecodeAll(data[1:], nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decompress record: %w", err)
+		}
+		return record, EncodingJSONZstd, nil
+	default:
+		return data, EncodingJSON, nil
+	}
+}
+
+type EncodingBenchmark struct {
+	Encoding string
+	Bytes    int
+	Encode   time.Duration
+	Decode   time.Duration
+}
+
+// BenchmarkEncodings stores every hotel in each encoding rounds times. Bytes
+// is the total size of one copy of the hotels; Encode and Decode are the mean
+// time per hotel.
+func BenchmarkEncodings(hotels []*domain.Hotel, rounds int) ([]EncodingBenchmark, error) {
+	if len(hotels) == 0 || rounds <= 0 {
+		return nil, fmt.Errorf("benchmark needs hotels and at least one round")
+	}
+
+	var results []EncodingBenchmark
+	for _, encoding := range Encodings {
+		result := EncodingBenchmark{Encoding: encoding}
+		stored := make([][]byte, len(hotels))
+
+		start := time.Now()
+		for round := 0; round < rounds; round++ {
+			for i, hotel := range hotels {
+				var err error
+				if stored[i], err = encodeAs(encoding, hotel); err != nil {
+					return nil, err
+				}
+			}
+		}
+		result.Encode = time.Since(start) / time.Duration(rounds*len(hotels))
+
+		for _, data := range stored {
+			result.Bytes += len(data)
+		}
+
+		start = time.Now()
+		for round := 0; round < rounds; round++ {
+			for _, data := range stored {
+				var hotel domain.Hotel
+				if _, _, err := decodeAs(data, &hotel, singleHotel); err != nil {
+					return nil, err
+				}
+			}
+		}
+		result.Decode = time.Since(start) / time.Duration(rounds*len(hotels))
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,138 @@
+package infra
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"hotelsdatapipeline/domain"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// codecTestHotel holds values JSON numbers decoded as float64 would change.
+func codecTestHotel() *domain.Hotel {
+	return &domain.Hotel{
+		HotelID:       "h1",
+		DestinationID: 1<<53 + 1,
+		HotelName:     "Grand",
+		Location:      domain.Location{Address: "1 Main St", Country: "SG", Lat: 1.0000000000000002, Lng: -0.1},
+		Amenities:     domain.Amenities{General: []string{"pool"}},
+		Images: domain.Images{"rooms": {{Link: "https://example.com/1.jpg", Health: &domain.ImageHealth{
+			Alive: true, StatusCode: 200, Size: 1<<62 + 1, CheckedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local),
+		}}}},
+		Rating:   domain.Rating{Stars: 4.5, ReviewCount: 1<<31 + 7},
+		Policies: domain.Policies{FreeCancellationHours: 48},
+		Sources:  []string{"acme"},
+	}
+}
+
+func TestEncodingsRoundTrip(t *testing.T) {
+	expires := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name   string
+		value  interface{}
+		target func() interface{}
+		hotels func(payload interface{}) []interface{}
+	}{
+		{"hotel", codecTestHotel(), func() interface{} { return &domain.Hotel{} }, singleHotel},
+		{"version", &domain.HotelVersion{Version: 3, RunID: "run", RecordedAt: expires.Add(-time.Hour),
+			Fingerprint: "f", Hotel: codecTestHotel(), ExpiresAt: &expires},
+			func() interface{} { return &domain.HotelVersion{} }, versionedHotel},
+		{"tombstone", &domain.HotelVersion{Version: 4, RecordedAt: expires, Deleted: true},
+			func() interface{} { return &domain.HotelVersion{} }, versionedHotel},
+	}
+
+	for _, encoding := range Encodings {
+		for _, tt := range tests {
+			t.Run(encoding+"/"+tt.name, func(t *testing.T) {
+				data, err := encodeAs(encoding, tt.value)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				target := tt.target()
+				migrated, stored, err := decodeAs(data, target, tt.hotels)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if migrated || stored != encoding {
+					t.Errorf("decodeAs reported migrated %v in %s, want false in %s", migrated, stored, encoding)
+				}
+
+				want, _ := json.Marshal(tt.value)
+				got, _ := json.Marshal(target)
+				if !bytes.Equal(got, want) {
+					t.Errorf("round trip changed the record:\n got %s\nwant %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeMsgpackConvertedFromJSON(t *testing.T) {
+	hotel := &domain.Hotel{HotelID: "h1", DestinationID: 7, Location: domain.Location{Lat: 1.5}}
+	record, err := encodeRecord(hotel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Older versions packed the generic value of the JSON record.
+	var value interface{}
+	if err := json.Unmarshal(record, &value); err != nil {
+		t.Fatal(err)
+	}
+	packed, err := msgpack.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded domain.Hotel
+	_, encoding, err := decodeAs(append([]byte{headerMsgpackJSON}, packed...), &decoded, singleHotel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoding == EncodingMsgpack {
+		t.Errorf("converted record reported as %s, so it would not be rewritten", encoding)
+	}
+	if decoded.HotelID != "h1" || decoded.DestinationID != 7 || decoded.Location.Lat != 1.5 {
+		t.Errorf("decoded %+v", decoded)
+	}
+}
+
+func TestDecodeMsgpackMigratesOlderSchema(t *testing.T) {
+	packed, err := msgpack.Marshal(msgpackEnvelope{SchemaVersion: 1, Data: map[string]interface{}{
+		"hotel_id":           "h1",
+		"destination_id":     int64(1<<53 + 1),
+		"booking_conditions": []string{"Free cancellation up to 48 hours before check-in"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hotel domain.Hotel
+	migrated, _, err := decodeAs(append([]byte{headerMsgpack}, packed...), &hotel, singleHotel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated {
+		t.Error("record of schema version 1 not migrated")
+	}
+	if hotel.DestinationID != 1<<53+1 {
+		t.Errorf("destination_id = %d, want %d", hotel.DestinationID, 1<<53+1)
+	}
+	if hotel.Policies.FreeCancellationHours != 48 {
+		t.Errorf("policies not derived: %+v", hotel.Policies)
+	}
+}
+
+func TestDecodeMsgpackRefusesNewerSchema(t *testing.T) {
+	packed, err := msgpack.Marshal(msgpackEnvelope{SchemaVersion: HotelSchemaVersion + 1, Data: map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hotel domain.Hotel
+	if _, _, err := decodeAs(append([]byte{headerMsgpack}, packed...), &hotel, singleHotel); err == nil {
+		t.Error("record of a newer schema version decoded")
+	}
+}
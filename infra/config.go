@@ -3,25 +3,118 @@ package infra
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"time"
+
+	"hotelsdatapipeline/domain"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Hotels  HotelsConfig  `yaml:"hotels"`
-	Redis   RedisConfig   `yaml:"redis"`
-	CronJob CronJobConfig `yaml:"cronjob"`
-	HTTP    HTTPConfig    `yaml:"http"`
+	Hotels       HotelsConfig       `yaml:"hotels"`
+	Storage      StorageConfig      `yaml:"storage"`
+	Redis        RedisConfig        `yaml:"redis"`
+	CronJob      CronJobConfig      `yaml:"cronjob"`
+	HTTP         HTTPConfig         `yaml:"http"`
+	Images       ImagesConfig       `yaml:"images"`
+	Matching     MatchingConfig     `yaml:"matching"`
+	Crosswalk    CrosswalkConfig    `yaml:"crosswalk"`
+	Locales      LocalesConfig      `yaml:"locales"`
+	Ratings      RatingsConfig      `yaml:"ratings"`
+	Dedupe       DedupeConfig       `yaml:"dedupe"`
+	Destinations DestinationsConfig `yaml:"destinations"`
+	Quality      QualityConfig      `yaml:"quality"`
+	History      HistoryConfig      `yaml:"history"`
+	TTL          TTLConfig          `yaml:"ttl"`
 }
 
 type HotelsConfig struct {
 	URLs []string `yaml:"urls"`
 }
 
+type StorageConfig struct {
+	Type     string                `yaml:"type"`
+	Memory   MemoryStorageConfig   `yaml:"memory"`
+	SQLite   SQLiteStorageConfig   `yaml:"sqlite"`
+	Postgres PostgresStorageConfig `yaml:"postgres"`
+}
+
+type MemoryStorageConfig struct {
+	SnapshotFile string `yaml:"snapshot_file"`
+}
+
+type SQLiteStorageConfig struct {
+	Path      string `yaml:"path"`
+	BatchSize int    `yaml:"batch_size"`
+}
+
+type PostgresStorageConfig struct {
+	DSN             string        `yaml:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	BatchSize       int           `yaml:"batch_size"`
+}
+
+// TTLConfig durations default to 24h when unset; 0 disables expiry, which is
+// meant to be paired with Reconcile so hotels dropped by suppliers are removed.
+type TTLConfig struct {
+	Hotels       *time.Duration `yaml:"hotels"`
+	Destinations *time.Duration `yaml:"destinations"`
+	Reports      *time.Duration `yaml:"reports"`
+	Reconcile    bool           `yaml:"reconcile"`
+}
+
+func (c TTLConfig) Policy() TTLPolicy {
+	orDefault := func(ttl *time.Duration) time.Duration {
+		if ttl == nil {
+			return 24 * time.Hour
+		}
+		return *ttl
+	}
+
+	return TTLPolicy{
+		Hotels:       orDefault(c.Hotels),
+		Destinations: orDefault(c.Destinations),
+		Reports:      orDefault(c.Reports),
+	}
+}
+
 type RedisConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
-	DB   int    `yaml:"db"`
+	Mode      string              `yaml:"mode"`
+	Namespace string              `yaml:"namespace"`
+	Encoding  string              `yaml:"encoding"`
+	BatchSize int                 `yaml:"batch_size"`
+	Host      string              `yaml:"host"`
+	Port      int                 `yaml:"port"`
+	DB        int                 `yaml:"db"`
+	Username  string              `yaml:"username"`
+	Password  string              `yaml:"password"`
+	TLS       RedisTLSConfig      `yaml:"tls"`
+	Sentinel  RedisSentinelConfig `yaml:"sentinel"`
+	Cluster   RedisClusterConfig  `yaml:"cluster"`
+}
+
+type RedisTLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+type RedisSentinelConfig struct {
+	MasterName string   `yaml:"master_name"`
+	Addrs      []string `yaml:"addrs"`
+	Username   string   `yaml:"username"`
+	Password   string   `yaml:"password"`
+}
+
+type RedisClusterConfig struct {
+	Addrs []string `yaml:"addrs"`
 }
 
 type CronJobConfig struct {
@@ -31,6 +124,78 @@ type CronJobConfig struct {
 type HTTPConfig struct {
 	Port int    `yaml:"port"`
 	Host string `yaml:"host"`
+	// AdminToken is the bearer token required by the /admin endpoints, which
+	// are disabled when it is empty.
+	AdminToken string `yaml:"admin_token"`
+}
+
+type ImagesConfig struct {
+	HealthCheck ImageHealthCheckConfig `yaml:"health_check"`
+}
+
+type ImageHealthCheckConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Interval    string        `yaml:"interval"`
+	Concurrency int           `yaml:"concurrency"`
+	Timeout     time.Duration `yaml:"timeout"`
+	CacheTTL    time.Duration `yaml:"cache_ttl"`
+}
+
+type MatchingConfig struct {
+	Threshold float64 `yaml:"threshold"`
+}
+
+type CrosswalkConfig struct {
+	File string `yaml:"file"`
+}
+
+type LocalesConfig struct {
+	Default   string              `yaml:"default"`
+	Fallbacks map[string][]string `yaml:"fallbacks"`
+}
+
+type RatingsConfig struct {
+	MergeRule string `yaml:"merge_rule"`
+	// ReviewScales is the review score scale (e.g. 5, 10 or 100) of each
+	// supplier, by supplier name, for scores delivered without one.
+	ReviewScales map[string]float64 `yaml:"review_scales"`
+}
+
+type DedupeConfig struct {
+	SimilarityThreshold float64 `yaml:"similarity_threshold"`
+}
+
+type DestinationsConfig struct {
+	File string `yaml:"file"`
+}
+
+type QualityConfig struct {
+	Weights                 map[string]float64 `yaml:"weights"`
+	DescriptionTargetLength int                `yaml:"description_target_length"`
+	ImageTargetCount        int                `yaml:"image_target_count"`
+	AmenityTargetCount      int                `yaml:"amenity_target_count"`
+}
+
+// Model overlays the configured weights and targets on the defaults.
+func (q QualityConfig) Model() domain.QualityModel {
+	model := domain.DefaultQualityModel()
+	if len(q.Weights) > 0 {
+		model.Weights = q.Weights
+	}
+	if q.DescriptionTargetLength > 0 {
+		model.DescriptionTargetLength = q.DescriptionTargetLength
+	}
+	if q.ImageTargetCount > 0 {
+		model.ImageTargetCount = q.ImageTargetCount
+	}
+	if q.AmenityTargetCount > 0 {
+		model.AmenityTargetCount = q.AmenityTargetCount
+	}
+	return model
+}
+
+type HistoryConfig struct {
+	MaxVersions int `yaml:"max_versions"`
 }
 
 func LoadConfig(filename string) (*Config, error) {
@@ -44,6 +209,18 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if config.Storage.Type == "" {
+		config.Storage.Type = StorageRedis
+	}
+
+	if config.Redis.Mode == "" {
+		config.Redis.Mode = RedisStandalone
+	}
+
+	if config.Redis.Encoding == "" {
+		config.Redis.Encoding = EncodingJSON
+	}
+
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -51,17 +228,97 @@ func LoadConfig(filename string) (*Config, error) {
 	return &config, nil
 }
 
+var redisNamespacePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+func (c RedisConfig) validate() error {
+	switch c.Mode {
+	case RedisStandalone:
+		if c.Host == "" {
+			return fmt.Errorf("Redis host is required")
+		}
+
+		if c.Port <= 0 || c.Port > 65535 {
+			return fmt.Errorf("Redis port must be between 1 and 65535")
+		}
+	case RedisSentinel:
+		if c.Sentinel.MasterName == "" || len(c.Sentinel.Addrs) == 0 {
+			return fmt.Errorf("Redis sentinel master name and addresses are required")
+		}
+	case RedisCluster:
+		if len(c.Cluster.Addrs) == 0 {
+			return fmt.Errorf("Redis cluster addresses are required")
+		}
+
+		if c.DB != 0 {
+			return fmt.Errorf("Redis cluster only supports db 0")
+		}
+	default:
+		return fmt.Errorf("Redis mode must be one of standalone, sentinel or cluster")
+	}
+
+	if c.Namespace != "" {
+		if !redisNamespacePattern.MatchString(c.Namespace) {
+			return fmt.Errorf("Redis namespace may only contain letters, digits, '.', '_' and '-'")
+		}
+		// A namespace named like a key family would make its keys, and its
+		// purge, overlap the keys written without a namespace.
+		for _, format := range redisKeyFormats {
+			if c.Namespace == redisKeyFamily(format) {
+				return fmt.Errorf("Redis namespace %s is reserved", c.Namespace)
+			}
+		}
+	}
+
+	knownEncoding := false
+	for _, encoding := range Encodings {
+		knownEncoding = knownEncoding || c.Encoding == encoding
+	}
+	if !knownEncoding {
+		return fmt.Errorf("Redis encoding must be one of json, msgpack, json+gzip or json+zstd")
+	}
+
+	if c.BatchSize < 0 {
+		return fmt.Errorf("Redis batch size must not be negative")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("Redis TLS cert file and key file must be set together")
+	}
+
+	return nil
+}
+
 func (c *Config) Validate() error {
 	if len(c.Hotels.URLs) == 0 {
 		return fmt.Errorf("at least one hotel supplier URL is required")
 	}
 
-	if c.Redis.Host == "" {
-		return fmt.Errorf("Redis host is required")
-	}
-
-	if c.Redis.Port <= 0 || c.Redis.Port > 65535 {
-		return fmt.Errorf("Redis port must be between 1 and 65535")
+	switch c.Storage.Type {
+	case StorageRedis:
+		if err := c.Redis.validate(); err != nil {
+			return err
+		}
+	case StorageMemory:
+	case StorageSQLite:
+		if c.Storage.SQLite.Path == "" {
+			return fmt.Errorf("SQLite path is required")
+		}
+		if c.Storage.SQLite.BatchSize < 0 {
+			return fmt.Errorf("SQLite batch size must not be negative")
+		}
+	case StoragePostgres:
+		if c.Storage.Postgres.DSN == "" {
+			return fmt.Errorf("PostgreSQL DSN is required")
+		}
+		if c.Storage.Postgres.MaxOpenConns < 0 || c.Storage.Postgres.MaxIdleConns < 0 || c.Storage.Postgres.BatchSize < 0 {
+			return fmt.Errorf("PostgreSQL pool and batch sizes must not be negative")
+		}
+		// Each upserted hotel binds 10 parameters and PostgreSQL allows 65535.
+		if c.Storage.Postgres.BatchSize > 5000 {
+			return fmt.Errorf("PostgreSQL batch size must be at most 5000")
+		}
+	default:
+		return fmt.Errorf("storage type must be one of redis, memory, sqlite or postgres")
 	}
 
 	if c.CronJob.Interval == "" {
@@ -76,5 +333,47 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("HTTP host is required")
 	}
 
+	if c.Images.HealthCheck.Enabled {
+		if c.Images.HealthCheck.Interval == "" {
+			return fmt.Errorf("image health check interval is required when enabled")
+		}
+		if c.Images.HealthCheck.Concurrency <= 0 {
+			return fmt.Errorf("image health check concurrency must be positive")
+		}
+		if c.Images.HealthCheck.Timeout <= 0 {
+			return fmt.Errorf("image health check timeout must be positive")
+		}
+	}
+
+	if c.Ratings.MergeRule != "" && !domain.IsValidRatingMergeRule(c.Ratings.MergeRule) {
+		return fmt.Errorf("ratings merge rule must be one of weighted, average or max")
+	}
+	for supplier, scale := range c.Ratings.ReviewScales {
+		if scale <= 0 {
+			return fmt.Errorf("review scale of supplier %s must be positive", supplier)
+		}
+	}
+
+	if c.Dedupe.SimilarityThreshold < 0 || c.Dedupe.SimilarityThreshold > 1 {
+		return fmt.Errorf("dedupe similarity threshold must be between 0 and 1")
+	}
+
+	if err := c.Quality.Model().Validate(); err != nil {
+		return fmt.Errorf("invalid quality settings: %w", err)
+	}
+
+	if c.History.MaxVersions < 0 {
+		return fmt.Errorf("history max versions must not be negative")
+	}
+
+	if c.Matching.Threshold < 0 || c.Matching.Threshold > 1 {
+		return fmt.Errorf("matching threshold must be between 0 and 1")
+	}
+
+	ttl := c.TTL.Policy()
+	if ttl.Hotels < 0 || ttl.Destinations < 0 || ttl.Reports < 0 {
+		return fmt.Errorf("TTLs must not be negative")
+	}
+
 	return nil
 }
@@ -0,0 +1,53 @@
+package infra
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hotelsdatapipeline/domain"
+)
+
+func LoadCrosswalkCSV(filename string) ([]domain.CrosswalkEntry, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crosswalk file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var entries []domain.CrosswalkEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse crosswalk file: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "supplier") {
+			continue
+		}
+
+		entry, err := domain.CrosswalkEntry{
+			Supplier:    record[0],
+			Kind:        record[1],
+			NativeID:    record[2],
+			CanonicalID: record[3],
+		}.Normalize()
+		if err != nil {
+			return nil, fmt.Errorf("invalid crosswalk record %v: %w", record, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
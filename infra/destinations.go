@@ -0,0 +1,34 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+
+	"hotelsdatapipeline/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+type destinationsFile struct {
+	Destinations []*domain.Destination `yaml:"destinations"`
+}
+
+func LoadDestinationsFile(filename string) ([]*domain.Destination, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destinations file: %w", err)
+	}
+
+	var file destinationsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse destinations file: %w", err)
+	}
+
+	for _, destination := range file.Destinations {
+		if destination.DestinationID <= 0 {
+			return nil, fmt.Errorf("destination ID must be positive: %d", destination.DestinationID)
+		}
+	}
+
+	return file.Destinations, nil
+}
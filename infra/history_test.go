@@ -0,0 +1,222 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+func hotelVersion(hotelID, fingerprint string) domain.HotelVersion {
+	return domain.HotelVersion{
+		RunID:       "run",
+		RecordedAt:  time.Now(),
+		Fingerprint: fingerprint,
+		Hotel:       &domain.Hotel{HotelID: hotelID, DestinationID: 1, HotelName: fingerprint},
+	}
+}
+
+func TestAppendHotelVersion(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		steps := []struct {
+			fingerprint string
+			want        int
+		}{
+			{"a", 1},
+			{"a", 0},
+			{"b", 2},
+			{"c", 3},
+			{"c", 0},
+			{"a", 4},
+		}
+		for _, step := range steps {
+			version, err := storage.AppendHotelVersion(ctx, hotelVersion("h1", step.fingerprint), 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != step.want {
+				t.Errorf("appending %q: got version %d, want %d", step.fingerprint, version, step.want)
+			}
+		}
+
+		history, err := storage.GetHotelHistory(ctx, "h1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var versions []int
+		for _, version := range history {
+			versions = append(versions, version.Version)
+		}
+		if fmt.Sprint(versions) != "[4 3 2]" {
+			t.Errorf("retained versions %v, want [4 3 2]", versions)
+		}
+	})
+}
+
+func TestAppendHotelVersionConcurrent(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+		const appends = 8
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var versions []int
+		for i := 0; i < appends; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Each append may need a retry when another one wins the race.
+				for attempt := 0; attempt < appends; attempt++ {
+					version, err := storage.AppendHotelVersion(ctx, hotelVersion("h1", fmt.Sprint(i)), appends)
+					if err != nil {
+						continue
+					}
+					mu.Lock()
+					versions = append(versions, version)
+					mu.Unlock()
+					return
+				}
+				t.Errorf("append %d kept failing", i)
+			}(i)
+		}
+		wg.Wait()
+
+		sort.Ints(versions)
+		seen := make(map[int]bool)
+		for _, version := range versions {
+			if version != 0 && seen[version] {
+				t.Errorf("version %d allocated twice: %v", version, versions)
+			}
+			seen[version] = true
+		}
+
+		history, err := storage.GetHotelHistory(ctx, "h1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < len(history); i++ {
+			if history[i].Version >= history[i-1].Version {
+				t.Errorf("history out of order: %d after %d", history[i].Version, history[i-1].Version)
+			}
+		}
+	})
+}
+
+func TestHotelTombstones(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		hotels := []*domain.Hotel{
+			{HotelID: "kept", DestinationID: 1, HotelName: "kept"},
+			{HotelID: "gone", DestinationID: 1, HotelName: "gone"},
+			{HotelID: "dup", DestinationID: 1, HotelName: "dup"},
+		}
+		if err := domain.BatchError(storage.StoreHotels(ctx, hotels)); err != nil {
+			t.Fatal(err)
+		}
+		for _, hotel := range hotels {
+			if _, err := storage.AppendHotelVersion(ctx, hotelVersion(hotel.HotelID, "a"), 10); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Versions are recorded with the current time, so the deletions are
+		// checked as of a moment after them.
+		if _, err := storage.DeleteHotelsExcept(ctx, []string{"kept", "dup"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.ApproveDuplicate(ctx, "kept", "dup"); err != nil {
+			t.Fatal(err)
+		}
+		asOf := time.Now().Add(time.Second)
+
+		for hotelID, wantLive := range map[string]bool{"kept": true, "gone": false, "dup": false} {
+			history, err := storage.GetHotelHistory(ctx, hotelID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := domain.HotelAsOf(history, asOf); ok != wantLive {
+				t.Errorf("hotel %s live after the deletions = %v, want %v", hotelID, ok, wantLive)
+			}
+		}
+
+		// A deleted hotel that comes back gets a new version even with the
+		// content it had before.
+		version, err := storage.AppendHotelVersion(ctx, hotelVersion("gone", "a"), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 3 {
+			t.Errorf("version after the tombstone = %d, want 3", version)
+		}
+	})
+}
+
+func TestHotelVersionExpiry(t *testing.T) {
+	forEachStorage(t, TTLPolicy{Hotels: time.Hour}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		if _, err := storage.AppendHotelVersion(ctx, hotelVersion("h1", "a"), 10); err != nil {
+			t.Fatal(err)
+		}
+		history, err := storage.GetHotelHistory(ctx, "h1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || history[0].ExpiresAt == nil {
+			t.Fatalf("want one version with an expiry, got %+v", history)
+		}
+		first := *history[0].ExpiresAt
+
+		time.Sleep(10 * time.Millisecond)
+		version, err := storage.AppendHotelVersion(ctx, hotelVersion("h1", "a"), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != 0 {
+			t.Errorf("unchanged hotel appended version %d", version)
+		}
+		history, err = storage.GetHotelHistory(ctx, "h1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || history[0].ExpiresAt == nil || !history[0].ExpiresAt.After(first) {
+			t.Errorf("expiry not moved forward from %s: %+v", first, history)
+		}
+	})
+}
+
+func TestDestinationHistoryHotelIDs(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		moved := hotelVersion("moved", "a")
+		if _, err := storage.AppendHotelVersion(ctx, moved, 10); err != nil {
+			t.Fatal(err)
+		}
+		moved = hotelVersion("moved", "b")
+		moved.Hotel.DestinationID = 2
+		if _, err := storage.AppendHotelVersion(ctx, moved, 10); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := storage.AppendHotelVersion(ctx, hotelVersion("stayed", "a"), 10); err != nil {
+			t.Fatal(err)
+		}
+
+		for destinationID, want := range map[int]string{1: "[moved stayed]", 2: "[moved]", 3: "[]"} {
+			hotelIDs, err := storage.GetDestinationHistoryHotelIDs(ctx, destinationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(hotelIDs)
+			if got := fmt.Sprint(hotelIDs); got != want {
+				t.Errorf("destination %d history hotels = %s, want %s", destinationID, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+func hotelIDs(hotels []*domain.Hotel) string {
+	ids := make([]string, len(hotels))
+	for i, hotel := range hotels {
+		ids[i] = hotel.HotelID
+	}
+	sort.Strings(ids)
+	return fmt.Sprint(ids)
+}
+
+func storeTestHotels(t *testing.T, storage Storage, hotels ...*domain.Hotel) {
+	t.Helper()
+	if err := domain.BatchError(storage.StoreHotels(context.Background(), hotels)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHotelsByDestination(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		storeTestHotels(t, storage,
+			&domain.Hotel{HotelID: "c", DestinationID: 1, HotelName: "C"},
+			&domain.Hotel{HotelID: "a", DestinationID: 1, HotelName: "A"},
+			&domain.Hotel{HotelID: "b", DestinationID: 1, HotelName: "B"},
+			&domain.Hotel{HotelID: "d", DestinationID: 2, HotelName: "D"},
+		)
+		// Moving a hotel takes it out of its old destination.
+		storeTestHotels(t, storage, &domain.Hotel{HotelID: "b", DestinationID: 2, HotelName: "B"})
+
+		tests := []struct {
+			destinationID int
+			page          domain.Page
+			want          string
+			wantTotal     int
+		}{
+			{1, domain.Page{}, "[a c]", 2},
+			{2, domain.Page{}, "[b d]", 2},
+			{2, domain.Page{Offset: 1, Limit: 1}, "[d]", 2},
+			{2, domain.Page{Offset: 5}, "[]", 2},
+			{3, domain.Page{}, "[]", 0},
+		}
+		for _, tt := range tests {
+			hotels, total, err := storage.GetHotelsByDestinationID(ctx, tt.destinationID, tt.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hotelIDs(hotels); got != tt.want || total != tt.wantTotal {
+				t.Errorf("destination %d page %+v = %s of %d, want %s of %d",
+					tt.destinationID, tt.page, got, total, tt.want, tt.wantTotal)
+			}
+		}
+
+		hotels, err := storage.GetHotelsByIDRange(ctx, []string{"a", "missing", "d"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hotelIDs(hotels); got != "[a d]" {
+			t.Errorf("GetHotelsByIDRange = %s, want [a d]", got)
+		}
+	})
+}
+
+func TestDeleteHotelsExcept(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		storeTestHotels(t, storage,
+			&domain.Hotel{HotelID: "a", DestinationID: 1, HotelName: "A"},
+			&domain.Hotel{HotelID: "b", DestinationID: 1, HotelName: "B"},
+			&domain.Hotel{HotelID: "c", DestinationID: 2, HotelName: "C"},
+		)
+
+		deleted, err := storage.DeleteHotelsExcept(ctx, []string{"a", "unknown"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 2 {
+			t.Errorf("deleted %d hotels, want 2", deleted)
+		}
+		hotels, err := storage.GetAllHotels(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hotelIDs(hotels); got != "[a]" {
+			t.Errorf("hotels left = %s, want [a]", got)
+		}
+		if _, total, err := storage.GetHotelsByDestinationID(ctx, 2, domain.Page{}); err != nil || total != 0 {
+			t.Errorf("destination 2 still has %d hotels (%v)", total, err)
+		}
+
+		// An empty list keeps nothing.
+		deleted, err = storage.DeleteHotelsExcept(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 1 {
+			t.Errorf("deleted %d hotels, want 1", deleted)
+		}
+		if hotels, err := storage.GetAllHotels(ctx); err != nil || len(hotels) != 0 {
+			t.Errorf("hotels left = %d (%v), want none", len(hotels), err)
+		}
+	})
+}
+
+func TestTouchAndExpireHotels(t *testing.T) {
+	// SQLite keeps expiry in whole seconds, hence the margins.
+	const ttl = 3 * time.Second
+	forEachStorage(t, TTLPolicy{Hotels: ttl}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		storeTestHotels(t, storage,
+			&domain.Hotel{HotelID: "touched", DestinationID: 1, HotelName: "T"},
+			&domain.Hotel{HotelID: "left", DestinationID: 1, HotelName: "L"},
+		)
+
+		time.Sleep(2 * time.Second)
+		errs := storage.TouchHotels(ctx, []*domain.Hotel{
+			{HotelID: "touched", DestinationID: 1},
+			{HotelID: "missing", DestinationID: 1},
+		})
+		if errs[0] != nil {
+			t.Errorf("touching a stored hotel failed: %v", errs[0])
+		}
+		if !errors.Is(errs[1], domain.ErrHotelNotStored) {
+			t.Errorf("touching a missing hotel = %v, want ErrHotelNotStored", errs[1])
+		}
+
+		time.Sleep(2 * time.Second)
+		if _, err := storage.GetHotelByID(ctx, "left"); err == nil {
+			t.Error("hotel still served after its TTL")
+		}
+		if _, err := storage.GetHotelByID(ctx, "touched"); err != nil {
+			t.Errorf("touched hotel expired: %v", err)
+		}
+		hotels, total, err := storage.GetHotelsByDestinationID(ctx, 1, domain.Page{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hotelIDs(hotels); got != "[touched]" || total != 1 {
+			t.Errorf("destination 1 = %s of %d, want [touched] of 1", got, total)
+		}
+	})
+}
+
+func TestApproveDuplicateRemovesHotel(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		storeTestHotels(t, storage,
+			&domain.Hotel{HotelID: "kept", DestinationID: 1, HotelName: "K"},
+			&domain.Hotel{HotelID: "dup", DestinationID: 1, HotelName: "D"},
+		)
+		if err := storage.ApproveDuplicate(ctx, "kept", "dup"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := storage.GetHotelByID(ctx, "dup"); err == nil {
+			t.Error("approved duplicate still served")
+		}
+		hotels, total, err := storage.GetHotelsByDestinationID(ctx, 1, domain.Page{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hotelIDs(hotels); got != "[kept]" || total != 1 {
+			t.Errorf("destination 1 = %s of %d, want [kept] of 1", got, total)
+		}
+		approved, err := storage.GetApprovedDuplicates(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if approved["dup"] != "kept" {
+			t.Errorf("approved duplicates = %v", approved)
+		}
+	})
+}
+
+func TestHotelFingerprints(t *testing.T) {
+	forEachStorage(t, TTLPolicy{}, func(t *testing.T, storage Storage) {
+		ctx := context.Background()
+
+		a := &domain.Hotel{HotelID: "a", DestinationID: 1, HotelName: "A"}
+		b := &domain.Hotel{HotelID: "b", DestinationID: 1, HotelName: "B"}
+		storeTestHotels(t, storage, a, b)
+
+		fingerprints, err := storage.GetHotelFingerprints(ctx, []string{"a", "b", "missing"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"a": a.RecordFingerprint(), "b": b.RecordFingerprint()}
+		if fmt.Sprint(fingerprints) != fmt.Sprint(want) {
+			t.Errorf("fingerprints = %v, want %v", fingerprints, want)
+		}
+
+		// Touching keeps the fingerprint; storing new content replaces it.
+		if err := domain.BatchError(storage.TouchHotels(ctx, []*domain.Hotel{b})); err != nil {
+			t.Fatal(err)
+		}
+		renamed := &domain.Hotel{HotelID: "a", DestinationID: 1, HotelName: "A2"}
+		storeTestHotels(t, storage, renamed)
+		if _, err := storage.DeleteHotelsExcept(ctx, []string{"a"}); err != nil {
+			t.Fatal(err)
+		}
+
+		fingerprints, err = storage.GetHotelFingerprints(ctx, []string{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = map[string]string{"a": renamed.RecordFingerprint()}
+		if fmt.Sprint(fingerprints) != fmt.Sprint(want) {
+			t.Errorf("fingerprints = %v, want %v", fingerprints, want)
+		}
+
+		if fingerprints, err := storage.GetHotelFingerprints(ctx, nil); err != nil || len(fingerprints) != 0 {
+			t.Errorf("fingerprints of no hotels = %v, %v", fingerprints, err)
+		}
+	})
+}
@@ -0,0 +1,852 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+// MemoryRepository keeps everything RedisRepository stores in process memory,
+// with the same expiry and missing-ID behaviour. Records are held encoded so
+// callers never share state with the store. When a snapshot file is set, the
+// contents are loaded from it on start and written back on Close.
+type MemoryRepository struct {
+	mu           sync.RWMutex
+	snapshotFile string
+	ttl          TTLPolicy
+	closeOnce    sync.Once
+	closeErr     error
+
+	hotels              map[string]memoryRecord
+	destinationHotels   map[int]map[string]bool
+	hotelDestinations   map[string]int
+	duplicateCandidates memoryRecord
+	approvedDuplicates  map[string]string
+	rejectedDuplicates  map[string]bool
+	crosswalkEntries    map[string]domain.CrosswalkEntry
+	crosswalkReport     memoryRecord
+	destinations        memoryRecord
+	qualityReport       memoryRecord
+	history             map[string][]json.RawMessage
+	historyDestinations map[int]map[string]bool
+}
+
+type memoryRecord struct {
+	Data      json.RawMessage `json:"data"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	// Fingerprint is the RecordFingerprint of a stored hotel.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+type memorySnapshot struct {
+	SavedAt             time.Time                        `json:"saved_at"`
+	Hotels              map[string]memoryRecord          `json:"hotels"`
+	DuplicateCandidates memoryRecord                     `json:"duplicate_candidates"`
+	ApprovedDuplicates  map[string]string                `json:"approved_duplicates"`
+	RejectedDuplicates  map[string]bool                  `json:"rejected_duplicates"`
+	CrosswalkEntries    map[string]domain.CrosswalkEntry `json:"crosswalk_entries"`
+	CrosswalkReport     memoryRecord                     `json:"crosswalk_report"`
+	Destinations        memoryRecord                     `json:"destinations"`
+	QualityReport       memoryRecord                     `json:"quality_report"`
+	History             map[string][]json.RawMessage     `json:"history"`
+}
+
+func NewMemoryRepository(snapshotFile string, ttl TTLPolicy) (*MemoryRepository, error) {
+	r := &MemoryRepository{
+		snapshotFile:        snapshotFile,
+		ttl:                 ttl,
+		hotels:              make(map[string]memoryRecord),
+		destinationHotels:   make(map[int]map[string]bool),
+		hotelDestinations:   make(map[string]int),
+		approvedDuplicates:  make(map[string]string),
+		rejectedDuplicates:  make(map[string]bool),
+		crosswalkEntries:    make(map[string]domain.CrosswalkEntry),
+		history:             make(map[string][]json.RawMessage),
+		historyDestinations: make(map[int]map[string]bool),
+	}
+
+	if snapshotFile == "" {
+		return r, nil
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// newRecord wraps data with an expiry after ttl; a zero ExpiresAt never
+// expires.
+func newRecord(data []byte, ttl time.Duration) memoryRecord {
+	record := memoryRecord{Data: data}
+	if ttl > 0 {
+		record.ExpiresAt = time.Now().Add(ttl)
+	}
+	return record
+}
+
+func (m memoryRecord) live() bool {
+	return len(m.Data) > 0 && (m.ExpiresAt.IsZero() || time.Now().Before(m.ExpiresAt))
+}
+
+// StoreHotels writes all hotels under one lock; there is no round trip to
+// batch.
+func (r *MemoryRepository) StoreHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+	if err := ctx.Err(); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	records := make([]memoryRecord, len(hotels))
+	for i, hotel := range hotels {
+		data, err := encodeRecord(hotel)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to marshal hotel %s: %w", hotel.HotelID, err)
+			continue
+		}
+		records[i] = memoryRecord{Data: data, Fingerprint: hotel.RecordFingerprint()}
+	}
+
+	stored := 0
+	r.mu.Lock()
+	r.evictExpired()
+	for i, hotel := range hotels {
+		if errs[i] == nil {
+			record := newRecord(records[i].Data, r.ttl.Hotels)
+			record.Fingerprint = records[i].Fingerprint
+			r.hotels[hotel.HotelID] = record
+			r.indexHotel(hotel.HotelID, hotel.DestinationID)
+			stored++
+		}
+	}
+	r.mu.Unlock()
+
+	log.Printf("Stored %d hotels", stored)
+	return errs
+}
+
+func (r *MemoryRepository) TouchHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+	if err := ctx.Err(); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	r.mu.Lock()
+	for i, hotel := range hotels {
+		record, ok := r.hotels[hotel.HotelID]
+		if !ok || !record.live() {
+			errs[i] = domain.ErrHotelNotStored
+			continue
+		}
+		touched := newRecord(record.Data, r.ttl.Hotels)
+		touched.Fingerprint = record.Fingerprint
+		r.hotels[hotel.HotelID] = touched
+	}
+	r.mu.Unlock()
+
+	return errs
+}
+
+func (r *MemoryRepository) DeleteHotelsExcept(ctx context.Context, hotelIDs []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	keep := make(map[string]bool, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		keep[hotelID] = true
+	}
+
+	deleted := 0
+	r.mu.Lock()
+	// Expired hotels are already gone as far as readers can tell, so they are
+	// evicted without counting as deleted or getting a tombstone.
+	r.evictExpired()
+	for hotelID := range r.hotels {
+		if !keep[hotelID] {
+			r.deleteHotel(hotelID)
+			r.buryHotel(hotelID)
+			deleted++
+		}
+	}
+	r.mu.Unlock()
+
+	log.Printf("Deleted %d hotels no longer supplied", deleted)
+	return deleted, nil
+}
+
+// indexHotel moves a hotel to its destination's index. The caller holds the
+// write lock.
+func (r *MemoryRepository) indexHotel(hotelID string, destinationID int) {
+	if previous, ok := r.hotelDestinations[hotelID]; ok {
+		delete(r.destinationHotels[previous], hotelID)
+	}
+	hotelIDs, ok := r.destinationHotels[destinationID]
+	if !ok {
+		hotelIDs = make(map[string]bool)
+		r.destinationHotels[destinationID] = hotelIDs
+	}
+	hotelIDs[hotelID] = true
+	r.hotelDestinations[hotelID] = destinationID
+}
+
+// deleteHotel deletes a hotel and its place in its destination's index. The
+// caller holds the write lock.
+func (r *MemoryRepository) deleteHotel(hotelID string) {
+	delete(r.hotels, hotelID)
+	if destinationID, ok := r.hotelDestinations[hotelID]; ok {
+		delete(r.destinationHotels[destinationID], hotelID)
+		delete(r.hotelDestinations, hotelID)
+	}
+}
+
+// evictExpired drops the hotels and reports whose TTL has passed, which reads
+// already skip. The caller holds the write lock.
+func (r *MemoryRepository) evictExpired() {
+	for hotelID, record := range r.hotels {
+		if !record.live() {
+			r.deleteHotel(hotelID)
+		}
+	}
+	for _, record := range []*memoryRecord{&r.duplicateCandidates, &r.crosswalkReport, &r.destinations, &r.qualityReport} {
+		if !record.live() {
+			*record = memoryRecord{}
+		}
+	}
+}
+
+func (r *MemoryRepository) GetHotelByID(ctx context.Context, hotelID string) (*domain.Hotel, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	record, ok := r.hotels[hotelID]
+	r.mu.RUnlock()
+	if !ok || !record.live() {
+		return nil, fmt.Errorf("hotel not found: %s", hotelID)
+	}
+
+	var hotel domain.Hotel
+	if _, err := decodeRecord(record.Data, &hotel, singleHotel); err != nil {
+		return nil, fmt.Errorf("failed to decode hotel %s: %w", hotelID, err)
+	}
+
+	return &hotel, nil
+}
+
+// GetHotelsByDestinationID pages through the destination's index and only
+// decodes the hotels on the requested page.
+func (r *MemoryRepository) GetHotelsByDestinationID(ctx context.Context, destinationID int, page domain.Page) ([]*domain.Hotel, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	var hotelIDs []string
+	for hotelID := range r.destinationHotels[destinationID] {
+		if r.hotels[hotelID].live() {
+			hotelIDs = append(hotelIDs, hotelID)
+		}
+	}
+	sort.Strings(hotelIDs)
+	start, end := page.Bounds(len(hotelIDs))
+	records := make([]memoryRecord, 0, end-start)
+	for _, hotelID := range hotelIDs[start:end] {
+		records = append(records, r.hotels[hotelID])
+	}
+	r.mu.RUnlock()
+
+	hotels := make([]*domain.Hotel, 0, len(records))
+	for i, record := range records {
+		var hotel domain.Hotel
+		if _, err := decodeRecord(record.Data, &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", hotelIDs[start+i], err)
+			continue
+		}
+		hotels = append(hotels, &hotel)
+	}
+
+	return hotels, len(hotelIDs), nil
+}
+
+func (r *MemoryRepository) GetHotelsByIDRange(ctx context.Context, hotelIDs []string) ([]*domain.Hotel, error) {
+	if len(hotelIDs) == 0 {
+		return []*domain.Hotel{}, nil
+	}
+
+	var hotels []*domain.Hotel
+	for _, hotelID := range hotelIDs {
+		hotel, err := r.GetHotelByID(ctx, hotelID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to get hotel %s: %v", hotelID, err)
+			continue
+		}
+		hotels = append(hotels, hotel)
+	}
+
+	return hotels, nil
+}
+
+func (r *MemoryRepository) GetHotelFingerprints(ctx context.Context, hotelIDs []string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fingerprints := make(map[string]string, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		if record, ok := r.hotels[hotelID]; ok && record.live() && record.Fingerprint != "" {
+			fingerprints[hotelID] = record.Fingerprint
+		}
+	}
+	return fingerprints, nil
+}
+
+func (r *MemoryRepository) GetAllHotels(ctx context.Context) ([]*domain.Hotel, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	records := make(map[string]memoryRecord, len(r.hotels))
+	for hotelID, record := range r.hotels {
+		if record.live() {
+			records[hotelID] = record
+		}
+	}
+	r.mu.RUnlock()
+
+	hotels := make([]*domain.Hotel, 0, len(records))
+	for hotelID, record := range records {
+		var hotel domain.Hotel
+		if _, err := decodeRecord(record.Data, &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", hotelID, err)
+			continue
+		}
+		hotels = append(hotels, &hotel)
+	}
+
+	return hotels, nil
+}
+
+func (r *MemoryRepository) StoreDuplicateCandidates(ctx context.Context, candidates []domain.DuplicateCandidate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal duplicate candidates: %w", err)
+	}
+
+	r.mu.Lock()
+	r.duplicateCandidates = newRecord(data, r.ttl.Reports)
+	r.mu.Unlock()
+
+	log.Printf("Stored %d duplicate candidates", len(candidates))
+	return nil
+}
+
+func (r *MemoryRepository) GetDuplicateCandidates(ctx context.Context) ([]domain.DuplicateCandidate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	record := r.duplicateCandidates
+	r.mu.RUnlock()
+	if !record.live() {
+		return []domain.DuplicateCandidate{}, nil
+	}
+
+	var candidates []domain.DuplicateCandidate
+	if err := json.Unmarshal(record.Data, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal duplicate candidates: %w", err)
+	}
+
+	return candidates, nil
+}
+
+func (r *MemoryRepository) ApproveDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.approvedDuplicates[duplicateID] = hotelID
+	delete(r.rejectedDuplicates, domain.DuplicatePairKey(hotelID, duplicateID))
+	r.deleteHotel(duplicateID)
+	r.buryHotel(duplicateID)
+	r.mu.Unlock()
+
+	log.Printf("Approved duplicate %s -> %s", duplicateID, hotelID)
+	return nil
+}
+
+func (r *MemoryRepository) RejectDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.rejectedDuplicates[domain.DuplicatePairKey(hotelID, duplicateID)] = true
+	r.mu.Unlock()
+
+	log.Printf("Rejected duplicate %s / %s", hotelID, duplicateID)
+	return nil
+}
+
+func (r *MemoryRepository) GetApprovedDuplicates(ctx context.Context) (domain.HotelIDCrosswalk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	approved := make(domain.HotelIDCrosswalk, len(r.approvedDuplicates))
+	for duplicateID, hotelID := range r.approvedDuplicates {
+		approved[duplicateID] = hotelID
+	}
+	return approved, nil
+}
+
+func (r *MemoryRepository) GetRejectedDuplicates(ctx context.Context) (map[string]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rejected := make(map[string]bool, len(r.rejectedDuplicates))
+	for pair := range r.rejectedDuplicates {
+		rejected[pair] = true
+	}
+	return rejected, nil
+}
+
+func (r *MemoryRepository) GetCrosswalkEntries(ctx context.Context) ([]domain.CrosswalkEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	entries := make([]domain.CrosswalkEntry, 0, len(r.crosswalkEntries))
+	for _, entry := range r.crosswalkEntries {
+		entries = append(entries, entry)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Supplier != b.Supplier {
+			return a.Supplier < b.Supplier
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.NativeID < b.NativeID
+	})
+
+	return entries, nil
+}
+
+func (r *MemoryRepository) StoreCrosswalkEntry(ctx context.Context, entry domain.CrosswalkEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	field := fmt.Sprintf("%s|%s|%s", entry.Supplier, entry.Kind, entry.NativeID)
+	r.mu.Lock()
+	r.crosswalkEntries[field] = entry
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *MemoryRepository) DeleteCrosswalkEntry(ctx context.Context, supplier, kind, nativeID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	field := fmt.Sprintf("%s|%s|%s", supplier, kind, nativeID)
+	r.mu.Lock()
+	delete(r.crosswalkEntries, field)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *MemoryRepository) StoreCrosswalkReport(ctx context.Context, report domain.CrosswalkReport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal crosswalk report: %w", err)
+	}
+
+	r.mu.Lock()
+	r.crosswalkReport = newRecord(data, r.ttl.Reports)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *MemoryRepository) GetCrosswalkReport(ctx context.Context) (*domain.CrosswalkReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	record := r.crosswalkReport
+	r.mu.RUnlock()
+	if !record.live() {
+		return &domain.CrosswalkReport{Unmapped: map[string]domain.UnmappedIDs{}}, nil
+	}
+
+	var report domain.CrosswalkReport
+	if err := json.Unmarshal(record.Data, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal crosswalk report: %w", err)
+	}
+
+	return &report, nil
+}
+
+func (r *MemoryRepository) StoreDestinations(ctx context.Context, destinations []*domain.Destination) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(destinations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal destinations: %w", err)
+	}
+
+	r.mu.Lock()
+	r.destinations = newRecord(data, r.ttl.Destinations)
+	r.mu.Unlock()
+
+	log.Printf("Stored %d destinations", len(destinations))
+	return nil
+}
+
+func (r *MemoryRepository) GetDestinationByID(ctx context.Context, destinationID int) (*domain.Destination, error) {
+	destinations, err := r.GetAllDestinations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, destination := range destinations {
+		if destination.DestinationID == destinationID {
+			return destination, nil
+		}
+	}
+	return nil, fmt.Errorf("destination not found: %d", destinationID)
+}
+
+func (r *MemoryRepository) GetAllDestinations(ctx context.Context) ([]*domain.Destination, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	record := r.destinations
+	r.mu.RUnlock()
+	if !record.live() {
+		return []*domain.Destination{}, nil
+	}
+
+	var destinations []*domain.Destination
+	if err := json.Unmarshal(record.Data, &destinations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal destinations: %w", err)
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].DestinationID < destinations[j].DestinationID
+	})
+
+	return destinations, nil
+}
+
+func (r *MemoryRepository) StoreQualityReport(ctx context.Context, report domain.QualityReport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quality report: %w", err)
+	}
+
+	r.mu.Lock()
+	r.qualityReport = newRecord(data, r.ttl.Reports)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *MemoryRepository) GetQualityReport(ctx context.Context) (*domain.QualityReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	record := r.qualityReport
+	r.mu.RUnlock()
+	if !record.live() {
+		return nil, fmt.Errorf("quality report not found")
+	}
+
+	var report domain.QualityReport
+	if err := json.Unmarshal(record.Data, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quality report: %w", err)
+	}
+
+	return &report, nil
+}
+
+func (r *MemoryRepository) AppendHotelVersion(ctx context.Context, version domain.HotelVersion, maxVersions int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	version.ExpiresAt = versionExpiry(r.ttl.Hotels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.appendHotelVersion(version.Hotel.HotelID, version, maxVersions)
+}
+
+// appendHotelVersion records version in the hotel's history, keeping at most
+// maxVersions; zero keeps them all. The caller holds the write lock.
+func (r *MemoryRepository) appendHotelVersion(hotelID string, version domain.HotelVersion, maxVersions int) (int, error) {
+	entries := r.history[hotelID]
+	var latest *domain.HotelVersion
+	if len(entries) > 0 {
+		latest = &domain.HotelVersion{}
+		if _, err := decodeRecord(entries[0], latest, versionedHotel); err != nil {
+			return 0, fmt.Errorf("failed to decode latest version: %w", err)
+		}
+	}
+
+	number, refresh := domain.NextHotelVersion(latest, version, time.Now())
+	if refresh {
+		latest.ExpiresAt = version.ExpiresAt
+		data, err := encodeRecord(latest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal hotel version: %w", err)
+		}
+		// Readers may still hold the old slice, so it is copied, not
+		// written in place.
+		entries = append([]json.RawMessage{data}, entries[1:]...)
+		r.history[hotelID] = entries
+	}
+	if number == 0 {
+		return 0, nil
+	}
+
+	version.Version = number
+	data, err := encodeRecord(version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal hotel version: %w", err)
+	}
+
+	history := append([]json.RawMessage{data}, entries...)
+	if maxVersions > 0 && len(history) > maxVersions {
+		history = history[:maxVersions]
+	}
+	r.history[hotelID] = history
+	if version.Hotel != nil {
+		r.indexHistory(hotelID, version.Hotel.DestinationID)
+	}
+
+	return number, nil
+}
+
+// buryHotel records that a hotel was deleted. Tombstones are never trimmed
+// on their own; the next append does. The caller holds the write lock.
+func (r *MemoryRepository) buryHotel(hotelID string) {
+	if _, err := r.appendHotelVersion(hotelID, domain.NewTombstone(time.Now()), 0); err != nil {
+		log.Printf("Failed to record deletion of hotel %s: %v", hotelID, err)
+	}
+}
+
+func (r *MemoryRepository) indexHistory(hotelID string, destinationID int) {
+	hotelIDs, ok := r.historyDestinations[destinationID]
+	if !ok {
+		hotelIDs = make(map[string]bool)
+		r.historyDestinations[destinationID] = hotelIDs
+	}
+	hotelIDs[hotelID] = true
+}
+
+func (r *MemoryRepository) GetHotelHistory(ctx context.Context, hotelID string) ([]domain.HotelVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	entries := r.history[hotelID]
+	r.mu.RUnlock()
+
+	history := make([]domain.HotelVersion, 0, len(entries))
+	for _, entry := range entries {
+		var version domain.HotelVersion
+		if _, err := decodeRecord(entry, &version, versionedHotel); err != nil {
+			log.Printf("Failed to decode version of hotel %s: %v", hotelID, err)
+			continue
+		}
+		history = append(history, version)
+	}
+
+	return history, nil
+}
+
+func (r *MemoryRepository) GetDestinationHistoryHotelIDs(ctx context.Context, destinationID int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hotelIDs := make([]string, 0, len(r.historyDestinations[destinationID]))
+	for hotelID := range r.historyDestinations[destinationID] {
+		hotelIDs = append(hotelIDs, hotelID)
+	}
+	return hotelIDs, nil
+}
+
+func (r *MemoryRepository) loadSnapshot() error {
+	data, err := os.ReadFile(r.snapshotFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	for hotelID, record := range snapshot.Hotels {
+		var hotel domain.Hotel
+		if _, err := decodeRecord(record.Data, &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", hotelID, err)
+			continue
+		}
+		r.hotels[hotelID] = record
+		r.indexHotel(hotelID, hotel.DestinationID)
+	}
+	for duplicateID, hotelID := range snapshot.ApprovedDuplicates {
+		r.approvedDuplicates[duplicateID] = hotelID
+	}
+	for pair := range snapshot.RejectedDuplicates {
+		r.rejectedDuplicates[pair] = true
+	}
+	for field, entry := range snapshot.CrosswalkEntries {
+		r.crosswalkEntries[field] = entry
+	}
+	for hotelID, history := range snapshot.History {
+		r.history[hotelID] = history
+		for _, entry := range history {
+			var version domain.HotelVersion
+			if _, err := decodeRecord(entry, &version, versionedHotel); err == nil && version.Hotel != nil {
+				r.indexHistory(hotelID, version.Hotel.DestinationID)
+			}
+		}
+	}
+	r.duplicateCandidates = snapshot.DuplicateCandidates
+	r.crosswalkReport = snapshot.CrosswalkReport
+	r.destinations = snapshot.Destinations
+	r.qualityReport = snapshot.QualityReport
+	r.evictExpired()
+
+	log.Printf("Loaded snapshot from %s saved at %s with %d hotels",
+		r.snapshotFile, snapshot.SavedAt.Format(time.RFC3339), len(snapshot.Hotels))
+	return nil
+}
+
+// saveSnapshot evicts expired records, writes the rest to a temporary file and
+// renames it over the snapshot so an interrupted save never leaves a truncated
+// file.
+func (r *MemoryRepository) saveSnapshot() error {
+	r.mu.Lock()
+	r.evictExpired()
+	snapshot := memorySnapshot{
+		SavedAt:             time.Now(),
+		Hotels:              r.hotels,
+		DuplicateCandidates: r.duplicateCandidates,
+		ApprovedDuplicates:  r.approvedDuplicates,
+		RejectedDuplicates:  r.rejectedDuplicates,
+		CrosswalkEntries:    r.crosswalkEntries,
+		CrosswalkReport:     r.crosswalkReport,
+		Destinations:        r.destinations,
+		QualityReport:       r.qualityReport,
+		History:             r.history,
+	}
+	data, err := json.Marshal(snapshot)
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.snapshotFile), filepath.Base(r.snapshotFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.snapshotFile); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	log.Printf("Saved snapshot with %d hotels to %s", len(snapshot.Hotels), r.snapshotFile)
+	return nil
+}
+
+func (r *MemoryRepository) Close() error {
+	r.closeOnce.Do(func() {
+		if r.snapshotFile != "" {
+			r.closeErr = r.saveSnapshot()
+		}
+	})
+	return r.closeErr
+}
@@ -0,0 +1,103 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+const memoryTestTTL = 50 * time.Millisecond
+
+func newMemoryTestRepository(t *testing.T, snapshotFile string) *MemoryRepository {
+	t.Helper()
+	repo, err := NewMemoryRepository(snapshotFile, TTLPolicy{Hotels: memoryTestTTL, Destinations: memoryTestTTL, Reports: memoryTestTTL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestMemoryEvictsExpiredOnWrite(t *testing.T) {
+	repo := newMemoryTestRepository(t, "")
+	ctx := context.Background()
+
+	storeTestHotels(t, repo, &domain.Hotel{HotelID: "old", DestinationID: 1, HotelName: "O"})
+	if err := repo.StoreQualityReport(ctx, domain.NewQualityReport([]float64{50})); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * memoryTestTTL)
+	storeTestHotels(t, repo, &domain.Hotel{HotelID: "new", DestinationID: 1, HotelName: "N"})
+
+	if _, ok := repo.hotels["old"]; ok {
+		t.Error("expired hotel not evicted")
+	}
+	if _, ok := repo.hotelDestinations["old"]; ok || repo.destinationHotels[1]["old"] {
+		t.Error("expired hotel left in the destination index")
+	}
+	if len(repo.qualityReport.Data) != 0 {
+		t.Error("expired quality report not evicted")
+	}
+	if _, ok := repo.hotels["new"]; !ok {
+		t.Error("stored hotel evicted")
+	}
+}
+
+func TestMemoryDeleteHotelsExceptSkipsExpired(t *testing.T) {
+	repo := newMemoryTestRepository(t, "")
+	ctx := context.Background()
+
+	storeTestHotels(t, repo,
+		&domain.Hotel{HotelID: "a", DestinationID: 1, HotelName: "A"},
+		&domain.Hotel{HotelID: "b", DestinationID: 1, HotelName: "B"},
+	)
+	time.Sleep(2 * memoryTestTTL)
+
+	deleted, err := repo.DeleteHotelsExcept(ctx, []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 0 {
+		t.Errorf("deleted %d hotels, want 0: both had expired", deleted)
+	}
+	if history, err := repo.GetHotelHistory(ctx, "b"); err != nil || len(history) != 0 {
+		t.Errorf("expired hotel got a tombstone: %+v (%v)", history, err)
+	}
+	if len(repo.hotels) != 0 {
+		t.Errorf("hotels left = %d, want none", len(repo.hotels))
+	}
+}
+
+func TestMemorySnapshotSkipsExpired(t *testing.T) {
+	snapshotFile := filepath.Join(t.TempDir(), "snapshot.json")
+	repo := newMemoryTestRepository(t, snapshotFile)
+
+	storeTestHotels(t, repo, &domain.Hotel{HotelID: "a", DestinationID: 1, HotelName: "A"})
+	if err := repo.StoreDestinations(context.Background(), []*domain.Destination{{DestinationID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * memoryTestTTL)
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	var destinations []*domain.Destination
+	if err := json.Unmarshal(snapshot.Destinations.Data, &destinations); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Hotels) != 0 || len(destinations) != 0 {
+		t.Errorf("snapshot kept expired records: %d hotels, %d destinations", len(snapshot.Hotels), len(destinations))
+	}
+}
@@ -0,0 +1,997 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hotelsdatapipeline/domain"
+
+	"github.com/lib/pq"
+)
+
+// postgresMigrations are applied in order on open; the index of each entry
+// plus one is its version in schema_migrations. Append new entries, never
+// edit applied ones.
+var postgresMigrations = []string{
+	`CREATE TABLE hotels (
+		hotel_id       TEXT PRIMARY KEY,
+		destination_id INTEGER NOT NULL,
+		name           TEXT NOT NULL,
+		country        TEXT NOT NULL,
+		lat            DOUBLE PRECISION,
+		lng            DOUBLE PRECISION,
+		schema_version INTEGER NOT NULL,
+		data           JSONB NOT NULL,
+		updated_at     TIMESTAMPTZ NOT NULL,
+		expires_at     TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_hotels_destination ON hotels (destination_id, expires_at);
+	CREATE INDEX idx_hotels_country ON hotels (country);
+	CREATE INDEX idx_hotels_coordinates ON hotels (lat, lng) WHERE lat IS NOT NULL;
+	CREATE INDEX idx_hotels_data ON hotels USING GIN (data jsonb_path_ops);
+
+	CREATE TABLE destinations (
+		destination_id INTEGER PRIMARY KEY,
+		name           TEXT NOT NULL,
+		country        TEXT NOT NULL,
+		data           JSONB NOT NULL,
+		expires_at     TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE hotel_history (
+		hotel_id       TEXT NOT NULL,
+		version        INTEGER NOT NULL,
+		run_id         TEXT NOT NULL,
+		recorded_at    TIMESTAMPTZ NOT NULL,
+		fingerprint    TEXT NOT NULL,
+		schema_version INTEGER NOT NULL,
+		data           JSONB NOT NULL,
+		PRIMARY KEY (hotel_id, version)
+	);
+
+	CREATE TABLE approved_duplicates (
+		duplicate_id TEXT PRIMARY KEY,
+		hotel_id     TEXT NOT NULL
+	);
+
+	CREATE TABLE rejected_duplicates (
+		pair_key TEXT PRIMARY KEY
+	);
+
+	CREATE TABLE crosswalk_entries (
+		supplier     TEXT NOT NULL,
+		kind         TEXT NOT NULL,
+		native_id    TEXT NOT NULL,
+		canonical_id TEXT NOT NULL,
+		PRIMARY KEY (supplier, kind, native_id)
+	);
+
+	CREATE TABLE reports (
+		name       TEXT PRIMARY KEY,
+		data       JSONB NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	);`,
+	`ALTER TABLE hotel_history
+		ADD COLUMN destination_id INTEGER,
+		ADD COLUMN deleted BOOLEAN NOT NULL DEFAULT false,
+		ADD COLUMN expires_at TIMESTAMPTZ;
+	UPDATE hotel_history SET destination_id = (data->>'destination_id')::integer;
+	CREATE INDEX idx_hotel_history_destination ON hotel_history (destination_id, hotel_id);`,
+	`ALTER TABLE hotels ADD COLUMN fingerprint TEXT;`,
+}
+
+// PostgresRepository stores hotels as JSONB alongside relational columns for
+// destination, country and coordinates so they can be queried directly.
+// Hotels are upserted in multi-row batches.
+type PostgresRepository struct {
+	db        *sql.DB
+	ttl       TTLPolicy
+	batchSize int
+}
+
+func NewPostgresRepository(config PostgresStorageConfig, ttl TTLPolicy) (*PostgresRepository, error) {
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PostgreSQL database: %w", err)
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	r := &PostgresRepository{db: db, ttl: ttl, batchSize: batchSize}
+	if err := r.migrateSchema(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// migrateSchema holds an advisory lock while migrating so several instances
+// starting together do not apply the same migration twice.
+func (r *PostgresRepository) migrateSchema(ctx context.Context) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext('hotelsdatapipeline_migrations'))`); err != nil {
+		return fmt.Errorf("failed to lock schema migrations: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext('hotelsdatapipeline_migrations'))`)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if current > len(postgresMigrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, len(postgresMigrations))
+	}
+
+	for version := current + 1; version <= len(postgresMigrations); version++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to apply PostgreSQL migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, postgresMigrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply PostgreSQL migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, now())`, version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply PostgreSQL migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to apply PostgreSQL migration %d: %w", version, err)
+		}
+		log.Printf("Applied PostgreSQL migration %d", version)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) expiresAt(ttl time.Duration) time.Time {
+	return expiryAfter(ttl)
+}
+
+// openPostgresRecord rebuilds the stored envelope from the schema_version and
+// data columns so it goes through the same migrations as other backends.
+func openPostgresRecord(version int, data []byte) []byte {
+	envelope, _ := json.Marshal(recordEnvelope{SchemaVersion: version, Data: data})
+	return envelope
+}
+
+// StoreHotels upserts hotels, including their destination membership, in
+// multi-row statements of up to the configured batch size, one transaction per
+// batch. When a batch fails, its hotels are retried one by one so the errors
+// point at the hotels that caused them.
+func (r *PostgresRepository) StoreHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+	if len(hotels) == 0 {
+		return errs
+	}
+
+	latest := make(map[string]*domain.Hotel, len(hotels))
+	ordered := make([]*domain.Hotel, 0, len(hotels))
+	for _, hotel := range hotels {
+		if _, seen := latest[hotel.HotelID]; !seen {
+			ordered = append(ordered, hotel)
+		}
+		latest[hotel.HotelID] = hotel
+	}
+
+	failures := make(map[string]error)
+	for start := 0; start < len(ordered); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(ordered) {
+			end = len(ordered)
+		}
+
+		batch := make([]*domain.Hotel, 0, end-start)
+		for _, hotel := range ordered[start:end] {
+			batch = append(batch, latest[hotel.HotelID])
+		}
+
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			return r.upsertHotels(ctx, tx, batch)
+		})
+		if err == nil {
+			continue
+		}
+		if len(batch) == 1 {
+			failures[batch[0].HotelID] = err
+			continue
+		}
+
+		for _, hotel := range batch {
+			single := []*domain.Hotel{hotel}
+			if err := r.withTx(ctx, func(tx *sql.Tx) error {
+				return r.upsertHotels(ctx, tx, single)
+			}); err != nil {
+				failures[hotel.HotelID] = err
+			}
+		}
+	}
+
+	for i, hotel := range hotels {
+		if err, failed := failures[hotel.HotelID]; failed {
+			errs[i] = fmt.Errorf("failed to store hotel %s: %w", hotel.HotelID, err)
+		}
+	}
+
+	log.Printf("Stored %d hotels", len(ordered)-len(failures))
+	return errs
+}
+
+func (r *PostgresRepository) upsertHotels(ctx context.Context, tx *sql.Tx, hotels []*domain.Hotel) error {
+	const columns = 11
+	now := time.Now()
+	expiresAt := r.expiresAt(r.ttl.Hotels)
+
+	values := make([]string, 0, len(hotels))
+	args := make([]interface{}, 0, len(hotels)*columns)
+	for i, hotel := range hotels {
+		data, err := json.Marshal(hotel)
+		if err != nil {
+			return fmt.Errorf("failed to marshal hotel %s: %w", hotel.HotelID, err)
+		}
+
+		var lat, lng sql.NullFloat64
+		if hotel.Location.HasCoordinates() {
+			lat = sql.NullFloat64{Float64: hotel.Location.Lat, Valid: true}
+			lng = sql.NullFloat64{Float64: hotel.Location.Lng, Valid: true}
+		}
+
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, hotel.HotelID, hotel.DestinationID, hotel.HotelName, hotel.Location.Country,
+			lat, lng, HotelSchemaVersion, string(data), hotel.RecordFingerprint(), now, expiresAt)
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO hotels
+		(hotel_id, destination_id, name, country, lat, lng, schema_version, data, fingerprint, updated_at, expires_at)
+		VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT (hotel_id) DO UPDATE SET
+			destination_id = EXCLUDED.destination_id,
+			name = EXCLUDED.name,
+			country = EXCLUDED.country,
+			lat = EXCLUDED.lat,
+			lng = EXCLUDED.lng,
+			schema_version = EXCLUDED.schema_version,
+			data = EXCLUDED.data,
+			fingerprint = EXCLUDED.fingerprint,
+			updated_at = EXCLUDED.updated_at,
+			expires_at = EXCLUDED.expires_at`, args...)
+	return err
+}
+
+// TouchHotels moves the expiry of stored hotels forward in one statement per
+// batch of the configured size.
+func (r *PostgresRepository) TouchHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+
+	for start := 0; start < len(hotels); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotels) {
+			end = len(hotels)
+		}
+
+		hotelIDs := make([]string, 0, end-start)
+		for _, hotel := range hotels[start:end] {
+			hotelIDs = append(hotelIDs, hotel.HotelID)
+		}
+
+		touched, err := r.touchHotels(ctx, hotelIDs)
+		for i, hotel := range hotels[start:end] {
+			switch {
+			case err != nil:
+				errs[start+i] = fmt.Errorf("failed to touch hotel %s: %w", hotel.HotelID, err)
+			case !touched[hotel.HotelID]:
+				errs[start+i] = domain.ErrHotelNotStored
+			}
+		}
+	}
+
+	return errs
+}
+
+func (r *PostgresRepository) touchHotels(ctx context.Context, hotelIDs []string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `UPDATE hotels SET expires_at = $1
+		WHERE hotel_id = ANY($2) AND expires_at > now()
+		RETURNING hotel_id`, r.expiresAt(r.ttl.Hotels), pq.Array(hotelIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	touched := make(map[string]bool, len(hotelIDs))
+	for rows.Next() {
+		var hotelID string
+		if err := rows.Scan(&hotelID); err != nil {
+			return nil, err
+		}
+		touched[hotelID] = true
+	}
+	return touched, rows.Err()
+}
+
+// DeleteHotelsExcept deletes the stored hotels not listed in hotelIDs.
+func (r *PostgresRepository) DeleteHotelsExcept(ctx context.Context, hotelIDs []string) (int, error) {
+	if hotelIDs == nil {
+		hotelIDs = []string{}
+	}
+
+	var deleted int
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `DELETE FROM hotels WHERE NOT (hotel_id = ANY($1)) RETURNING hotel_id`, pq.Array(hotelIDs))
+		if err != nil {
+			return err
+		}
+		var stale []string
+		for rows.Next() {
+			var hotelID string
+			if err := rows.Scan(&hotelID); err != nil {
+				rows.Close()
+				return err
+			}
+			stale = append(stale, hotelID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		deleted = len(stale)
+		return buryPostgresHotels(ctx, tx, stale)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete hotels: %w", err)
+	}
+
+	log.Printf("Deleted %d hotels no longer supplied", deleted)
+	return deleted, nil
+}
+
+func (r *PostgresRepository) GetHotelByID(ctx context.Context, hotelID string) (*domain.Hotel, error) {
+	var version int
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT schema_version, data FROM hotels WHERE hotel_id = $1 AND expires_at > now()`,
+		hotelID).Scan(&version, &data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("hotel not found: %s", hotelID)
+		}
+		return nil, fmt.Errorf("failed to get hotel: %w", err)
+	}
+
+	var hotel domain.Hotel
+	if _, err := decodeRecord(openPostgresRecord(version, data), &hotel, singleHotel); err != nil {
+		return nil, fmt.Errorf("failed to decode hotel %s: %w", hotelID, err)
+	}
+
+	return &hotel, nil
+}
+
+func (r *PostgresRepository) GetHotelsByDestinationID(ctx context.Context, destinationID int, page domain.Page) ([]*domain.Hotel, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM hotels WHERE destination_id = $1 AND expires_at > now()`,
+		destinationID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count hotels by destination: %w", err)
+	}
+
+	start, end := page.Bounds(total)
+	if start >= end {
+		return []*domain.Hotel{}, total, nil
+	}
+
+	hotels, err := r.queryHotels(ctx, `SELECT hotel_id, schema_version, data FROM hotels
+		WHERE destination_id = $1 AND expires_at > now() ORDER BY hotel_id LIMIT $2 OFFSET $3`,
+		destinationID, end-start, start)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get hotels by destination: %w", err)
+	}
+	return hotels, total, nil
+}
+
+func (r *PostgresRepository) GetHotelsByIDRange(ctx context.Context, hotelIDs []string) ([]*domain.Hotel, error) {
+	if len(hotelIDs) == 0 {
+		return []*domain.Hotel{}, nil
+	}
+
+	hotels, err := r.queryHotels(ctx, `SELECT hotel_id, schema_version, data FROM hotels
+		WHERE hotel_id = ANY($1) AND expires_at > now()`, pq.Array(hotelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotels: %w", err)
+	}
+
+	if len(hotels) < len(hotelIDs) {
+		found := make(map[string]bool, len(hotels))
+		for _, hotel := range hotels {
+			found[hotel.HotelID] = true
+		}
+		for _, hotelID := range hotelIDs {
+			if !found[hotelID] {
+				log.Printf("Hotel not found: %s", hotelID)
+			}
+		}
+	}
+
+	return hotels, nil
+}
+
+func (r *PostgresRepository) GetHotelFingerprints(ctx context.Context, hotelIDs []string) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT hotel_id, fingerprint FROM hotels
+		WHERE hotel_id = ANY($1) AND expires_at > now() AND fingerprint IS NOT NULL`, pq.Array(hotelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel fingerprints: %w", err)
+	}
+	defer rows.Close()
+
+	fingerprints := make(map[string]string, len(hotelIDs))
+	for rows.Next() {
+		var hotelID, fingerprint string
+		if err := rows.Scan(&hotelID, &fingerprint); err != nil {
+			return nil, fmt.Errorf("failed to scan hotel fingerprint: %w", err)
+		}
+		fingerprints[hotelID] = fingerprint
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get hotel fingerprints: %w", err)
+	}
+	return fingerprints, nil
+}
+
+func (r *PostgresRepository) GetAllHotels(ctx context.Context) ([]*domain.Hotel, error) {
+	hotels, err := r.queryHotels(ctx, `SELECT hotel_id, schema_version, data FROM hotels WHERE expires_at > now()`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotels: %w", err)
+	}
+	return hotels, nil
+}
+
+func (r *PostgresRepository) queryHotels(ctx context.Context, query string, args ...interface{}) ([]*domain.Hotel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hotels := []*domain.Hotel{}
+	for rows.Next() {
+		var hotelID string
+		var version int
+		var data []byte
+		if err := rows.Scan(&hotelID, &version, &data); err != nil {
+			return nil, err
+		}
+
+		var hotel domain.Hotel
+		if _, err := decodeRecord(openPostgresRecord(version, data), &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", hotelID, err)
+			continue
+		}
+		hotels = append(hotels, &hotel)
+	}
+
+	return hotels, rows.Err()
+}
+
+func (r *PostgresRepository) storeReport(ctx context.Context, name string, report interface{}) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO reports (name, data, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at`,
+		name, string(data), r.expiresAt(r.ttl.Reports))
+	return err
+}
+
+// getReport decodes the named report into target and reports whether a live
+// one was found.
+func (r *PostgresRepository) getReport(ctx context.Context, name string, target interface{}) (bool, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM reports WHERE name = $1 AND expires_at > now()`, name).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresRepository) StoreDuplicateCandidates(ctx context.Context, candidates []domain.DuplicateCandidate) error {
+	if err := r.storeReport(ctx, reportDuplicateCandidates, candidates); err != nil {
+		return fmt.Errorf("failed to store duplicate candidates: %w", err)
+	}
+
+	log.Printf("Stored %d duplicate candidates", len(candidates))
+	return nil
+}
+
+func (r *PostgresRepository) GetDuplicateCandidates(ctx context.Context) ([]domain.DuplicateCandidate, error) {
+	candidates := []domain.DuplicateCandidate{}
+	if _, err := r.getReport(ctx, reportDuplicateCandidates, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to get duplicate candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+func (r *PostgresRepository) ApproveDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO approved_duplicates (duplicate_id, hotel_id) VALUES ($1, $2)
+			ON CONFLICT (duplicate_id) DO UPDATE SET hotel_id = EXCLUDED.hotel_id`, duplicateID, hotelID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM rejected_duplicates WHERE pair_key = $1`, domain.DuplicatePairKey(hotelID, duplicateID)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM hotels WHERE hotel_id = $1`, duplicateID); err != nil {
+			return err
+		}
+		return buryPostgresHotels(ctx, tx, []string{duplicateID})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to approve duplicate: %w", err)
+	}
+
+	log.Printf("Approved duplicate %s -> %s", duplicateID, hotelID)
+	return nil
+}
+
+func (r *PostgresRepository) RejectDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO rejected_duplicates (pair_key) VALUES ($1) ON CONFLICT DO NOTHING`,
+		domain.DuplicatePairKey(hotelID, duplicateID)); err != nil {
+		return fmt.Errorf("failed to reject duplicate: %w", err)
+	}
+
+	log.Printf("Rejected duplicate %s / %s", hotelID, duplicateID)
+	return nil
+}
+
+func (r *PostgresRepository) GetApprovedDuplicates(ctx context.Context) (domain.HotelIDCrosswalk, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT duplicate_id, hotel_id FROM approved_duplicates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approved duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	approved := make(domain.HotelIDCrosswalk)
+	for rows.Next() {
+		var duplicateID, hotelID string
+		if err := rows.Scan(&duplicateID, &hotelID); err != nil {
+			return nil, fmt.Errorf("failed to get approved duplicates: %w", err)
+		}
+		approved[duplicateID] = hotelID
+	}
+
+	return approved, rows.Err()
+}
+
+func (r *PostgresRepository) GetRejectedDuplicates(ctx context.Context) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT pair_key FROM rejected_duplicates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rejected duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	rejected := make(map[string]bool)
+	for rows.Next() {
+		var pair string
+		if err := rows.Scan(&pair); err != nil {
+			return nil, fmt.Errorf("failed to get rejected duplicates: %w", err)
+		}
+		rejected[pair] = true
+	}
+
+	return rejected, rows.Err()
+}
+
+func (r *PostgresRepository) GetCrosswalkEntries(ctx context.Context) ([]domain.CrosswalkEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT supplier, kind, native_id, canonical_id FROM crosswalk_entries
+		ORDER BY supplier, kind, native_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crosswalk entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.CrosswalkEntry{}
+	for rows.Next() {
+		var entry domain.CrosswalkEntry
+		if err := rows.Scan(&entry.Supplier, &entry.Kind, &entry.NativeID, &entry.CanonicalID); err != nil {
+			return nil, fmt.Errorf("failed to get crosswalk entries: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *PostgresRepository) StoreCrosswalkEntry(ctx context.Context, entry domain.CrosswalkEntry) error {
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO crosswalk_entries (supplier, kind, native_id, canonical_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (supplier, kind, native_id) DO UPDATE SET canonical_id = EXCLUDED.canonical_id`,
+		entry.Supplier, entry.Kind, entry.NativeID, entry.CanonicalID); err != nil {
+		return fmt.Errorf("failed to store crosswalk entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) DeleteCrosswalkEntry(ctx context.Context, supplier, kind, nativeID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM crosswalk_entries WHERE supplier = $1 AND kind = $2 AND native_id = $3`,
+		supplier, kind, nativeID); err != nil {
+		return fmt.Errorf("failed to delete crosswalk entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) StoreCrosswalkReport(ctx context.Context, report domain.CrosswalkReport) error {
+	if err := r.storeReport(ctx, reportCrosswalk, report); err != nil {
+		return fmt.Errorf("failed to store crosswalk report: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetCrosswalkReport(ctx context.Context) (*domain.CrosswalkReport, error) {
+	var report domain.CrosswalkReport
+	found, err := r.getReport(ctx, reportCrosswalk, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crosswalk report: %w", err)
+	}
+	if !found {
+		return &domain.CrosswalkReport{Unmapped: map[string]domain.UnmappedIDs{}}, nil
+	}
+	return &report, nil
+}
+
+func (r *PostgresRepository) StoreDestinations(ctx context.Context, destinations []*domain.Destination) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM destinations`); err != nil {
+			return err
+		}
+		expiresAt := r.expiresAt(r.ttl.Destinations)
+		for _, destination := range destinations {
+			data, err := json.Marshal(destination)
+			if err != nil {
+				return fmt.Errorf("failed to marshal destination %d: %w", destination.DestinationID, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO destinations (destination_id, name, country, data, expires_at)
+				VALUES ($1, $2, $3, $4, $5)`,
+				destination.DestinationID, destination.Name, destination.Country, string(data), expiresAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store destinations: %w", err)
+	}
+
+	log.Printf("Stored %d destinations", len(destinations))
+	return nil
+}
+
+func (r *PostgresRepository) GetDestinationByID(ctx context.Context, destinationID int) (*domain.Destination, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM destinations WHERE destination_id = $1 AND expires_at > now()`,
+		destinationID).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("destination not found: %d", destinationID)
+		}
+		return nil, fmt.Errorf("failed to get destination: %w", err)
+	}
+
+	var destination domain.Destination
+	if err := json.Unmarshal(data, &destination); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal destination: %w", err)
+	}
+
+	return &destination, nil
+}
+
+func (r *PostgresRepository) GetAllDestinations(ctx context.Context) ([]*domain.Destination, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT destination_id, data FROM destinations WHERE expires_at > now() ORDER BY destination_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destinations: %w", err)
+	}
+	defer rows.Close()
+
+	destinations := []*domain.Destination{}
+	for rows.Next() {
+		var destinationID int
+		var data []byte
+		if err := rows.Scan(&destinationID, &data); err != nil {
+			return nil, fmt.Errorf("failed to get destinations: %w", err)
+		}
+
+		var destination domain.Destination
+		if err := json.Unmarshal(data, &destination); err != nil {
+			log.Printf("Failed to unmarshal destination %d: %v", destinationID, err)
+			continue
+		}
+		destinations = append(destinations, &destination)
+	}
+
+	return destinations, rows.Err()
+}
+
+func (r *PostgresRepository) StoreQualityReport(ctx context.Context, report domain.QualityReport) error {
+	if err := r.storeReport(ctx, reportQuality, report); err != nil {
+		return fmt.Errorf("failed to store quality report: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetQualityReport(ctx context.Context) (*domain.QualityReport, error) {
+	var report domain.QualityReport
+	found, err := r.getReport(ctx, reportQuality, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quality report: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("quality report not found")
+	}
+	return &report, nil
+}
+
+// AppendHotelVersion numbers the version from the newest stored one while
+// holding a transaction-scoped advisory lock on the hotel, so overlapping
+// appends for the same hotel run one after the other.
+func (r *PostgresRepository) AppendHotelVersion(ctx context.Context, version domain.HotelVersion, maxVersions int) (int, error) {
+	data, err := json.Marshal(version.Hotel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal hotel version: %w", err)
+	}
+	version.ExpiresAt = versionExpiry(r.ttl.Hotels)
+
+	hotelID := version.Hotel.HotelID
+	appended := 0
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('hotel_history:' || $1))`, hotelID); err != nil {
+			return err
+		}
+
+		var latest *domain.HotelVersion
+		var previous domain.HotelVersion
+		err := tx.QueryRowContext(ctx, `SELECT version, fingerprint, deleted, expires_at FROM hotel_history
+			WHERE hotel_id = $1 ORDER BY version DESC LIMIT 1`, hotelID).
+			Scan(&previous.Version, &previous.Fingerprint, &previous.Deleted, &previous.ExpiresAt)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			latest = &previous
+		}
+
+		number, refresh := domain.NextHotelVersion(latest, version, time.Now())
+		if refresh {
+			if _, err := tx.ExecContext(ctx, `UPDATE hotel_history SET expires_at = $1 WHERE hotel_id = $2 AND version = $3`,
+				version.ExpiresAt, hotelID, latest.Version); err != nil {
+				return err
+			}
+		}
+		if number == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO hotel_history
+			(hotel_id, version, run_id, recorded_at, fingerprint, schema_version, data, destination_id, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			hotelID, number, version.RunID, version.RecordedAt, version.Fingerprint, HotelSchemaVersion, string(data),
+			version.Hotel.DestinationID, version.ExpiresAt); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM hotel_history WHERE hotel_id = $1 AND version NOT IN (
+			SELECT version FROM hotel_history WHERE hotel_id = $1 ORDER BY version DESC LIMIT $2)`,
+			hotelID, maxVersions); err != nil {
+			return err
+		}
+		appended = number
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store hotel version: %w", err)
+	}
+
+	return appended, nil
+}
+
+// buryPostgresHotels appends a tombstone within tx to the history of each
+// hotel whose newest version is live, in one statement. A version appended
+// concurrently wins over the tombstone. Tombstones are never trimmed on their
+// own; the next append does.
+func buryPostgresHotels(ctx context.Context, tx *sql.Tx, hotelIDs []string) error {
+	if len(hotelIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO hotel_history
+		(hotel_id, version, run_id, recorded_at, fingerprint, schema_version, data, deleted)
+		SELECT hotel_id, version + 1, '', now(), '', $2::integer, 'null'::jsonb, true
+		FROM (
+			SELECT DISTINCT ON (hotel_id) hotel_id, version, deleted, expires_at
+			FROM hotel_history WHERE hotel_id = ANY($1)
+			ORDER BY hotel_id, version DESC
+		) latest
+		WHERE NOT deleted AND (expires_at IS NULL OR expires_at > now())
+		ON CONFLICT (hotel_id, version) DO NOTHING`, pq.Array(hotelIDs), HotelSchemaVersion)
+	if err != nil {
+		return fmt.Errorf("failed to record deletion of hotels: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetHotelHistory(ctx context.Context, hotelID string) ([]domain.HotelVersion, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT version, run_id, recorded_at, fingerprint, deleted, expires_at, schema_version, data
+		FROM hotel_history WHERE hotel_id = $1 ORDER BY version DESC`, hotelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []domain.HotelVersion{}
+	for rows.Next() {
+		var version domain.HotelVersion
+		var schemaVersion int
+		var data []byte
+		if err := rows.Scan(&version.Version, &version.RunID, &version.RecordedAt, &version.Fingerprint,
+			&version.Deleted, &version.ExpiresAt, &schemaVersion, &data); err != nil {
+			return nil, fmt.Errorf("failed to get hotel history: %w", err)
+		}
+		if version.Deleted {
+			history = append(history, version)
+			continue
+		}
+
+		var hotel domain.Hotel
+		if _, err := decodeRecord(openPostgresRecord(schemaVersion, data), &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode version %d of hotel %s: %v", version.Version, hotelID, err)
+			continue
+		}
+		version.Hotel = &hotel
+		history = append(history, version)
+	}
+
+	return history, rows.Err()
+}
+
+func (r *PostgresRepository) GetDestinationHistoryHotelIDs(ctx context.Context, destinationID int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT hotel_id FROM hotel_history WHERE destination_id = $1`, destinationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel history IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var hotelIDs []string
+	for rows.Next() {
+		var hotelID string
+		if err := rows.Scan(&hotelID); err != nil {
+			return nil, fmt.Errorf("failed to get hotel history IDs: %w", err)
+		}
+		hotelIDs = append(hotelIDs, hotelID)
+	}
+
+	return hotelIDs, rows.Err()
+}
+
+// MigrateRecords rewrites hotel and history rows whose schema_version is older
+// than HotelSchemaVersion. Each table is migrated in one transaction with the
+// outdated rows locked, so they can be addressed by ctid.
+func (r *PostgresRepository) MigrateRecords(ctx context.Context) (MigrationReport, error) {
+	var report MigrationReport
+
+	for _, table := range []string{"hotels", "hotel_history"} {
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, `SELECT ctid::text, schema_version, data FROM `+table+`
+				WHERE schema_version < $1 FOR UPDATE`, HotelSchemaVersion)
+			if err != nil {
+				return err
+			}
+
+			updates := make(map[string][]byte)
+			for rows.Next() {
+				var rowID string
+				var version int
+				var data []byte
+				if err := rows.Scan(&rowID, &version, &data); err != nil {
+					rows.Close()
+					return err
+				}
+				report.Scanned++
+
+				var hotel domain.Hotel
+				if _, err := decodeRecord(openPostgresRecord(version, data), &hotel, singleHotel); err != nil {
+					log.Printf("Failed to decode %s row %s: %v", table, rowID, err)
+					report.Failed++
+					continue
+				}
+
+				encoded, err := json.Marshal(&hotel)
+				if err != nil {
+					log.Printf("Failed to encode %s row %s: %v", table, rowID, err)
+					report.Failed++
+					continue
+				}
+				updates[rowID] = encoded
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for rowID, data := range updates {
+				if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET schema_version = $1, data = $2 WHERE ctid = $3::tid`,
+					HotelSchemaVersion, string(data), rowID); err != nil {
+					return err
+				}
+			}
+			report.Migrated += len(updates)
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to migrate %s: %w", table, err)
+		}
+	}
+
+	return report, nil
+}
+
+func (r *PostgresRepository) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,108 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"hotelsdatapipeline/domain"
+)
+
+func countPostgresHotels(t *testing.T, repo *PostgresRepository) int {
+	t.Helper()
+	var count int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM hotels`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestPostgresStoreHotelsInBatches(t *testing.T) {
+	repo := newPostgresTestRepository(t, TTLPolicy{}, 2)
+	ctx := context.Background()
+
+	// Five distinct hotels make three batches; the repeated ID is written
+	// once, with its last copy.
+	hotels := []*domain.Hotel{
+		{HotelID: "a", DestinationID: 1, HotelName: "A"},
+		{HotelID: "b", DestinationID: 1, HotelName: "B"},
+		{HotelID: "c", DestinationID: 2, HotelName: "C"},
+		{HotelID: "a", DestinationID: 1, HotelName: "A2"},
+		{HotelID: "d", DestinationID: 2, HotelName: "D"},
+		{HotelID: "e", DestinationID: 2, HotelName: "E"},
+	}
+	if err := domain.BatchError(repo.StoreHotels(ctx, hotels)); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := countPostgresHotels(t, repo); count != 5 {
+		t.Errorf("stored %d rows, want 5", count)
+	}
+	hotel, err := repo.GetHotelByID(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hotel.HotelName != "A2" {
+		t.Errorf("hotel a is %q, want the last copy A2", hotel.HotelName)
+	}
+	if _, total, err := repo.GetHotelsByDestinationID(ctx, 2, domain.Page{}); err != nil || total != 3 {
+		t.Errorf("destination 2 has %d hotels (%v), want 3", total, err)
+	}
+}
+
+func TestPostgresStoreHotelsRetriesFailedBatchOneByOne(t *testing.T) {
+	repo := newPostgresTestRepository(t, TTLPolicy{}, 3)
+	ctx := context.Background()
+
+	// PostgreSQL rejects NUL in text, which fails the whole multi-row insert.
+	hotels := []*domain.Hotel{
+		{HotelID: "ok1", DestinationID: 1, HotelName: "OK 1"},
+		{HotelID: "bad", DestinationID: 1, HotelName: "Bad\x00Name"},
+		{HotelID: "ok2", DestinationID: 1, HotelName: "OK 2"},
+	}
+	errs := repo.StoreHotels(ctx, hotels)
+
+	for i, hotel := range hotels {
+		if wantErr := hotel.HotelID == "bad"; (errs[i] != nil) != wantErr {
+			t.Errorf("hotel %s error = %v, want error %v", hotel.HotelID, errs[i], wantErr)
+		}
+	}
+	if count := countPostgresHotels(t, repo); count != 2 {
+		t.Errorf("stored %d rows, want the 2 valid hotels", count)
+	}
+}
+
+func TestPostgresDeleteHotelsExceptEmptyList(t *testing.T) {
+	repo := newPostgresTestRepository(t, TTLPolicy{}, 2)
+	ctx := context.Background()
+
+	hotels := []*domain.Hotel{
+		{HotelID: "a", DestinationID: 1, HotelName: "A"},
+		{HotelID: "b", DestinationID: 1, HotelName: "B"},
+		{HotelID: "c", DestinationID: 2, HotelName: "C"},
+	}
+	if err := domain.BatchError(repo.StoreHotels(ctx, hotels)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AppendHotelVersion(ctx, hotelVersion("a", "a"), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := repo.DeleteHotelsExcept(ctx, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Errorf("deleted %d hotels, want 3", deleted)
+	}
+	if count := countPostgresHotels(t, repo); count != 0 {
+		t.Errorf("%d rows left, want none", count)
+	}
+
+	history, err := repo.GetHotelHistory(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || !history[0].Deleted {
+		t.Errorf("want a tombstone on top of the recorded version, got %+v", history)
+	}
+}
@@ -2,9 +2,16 @@ package infra
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"hotelsdatapipeline/domain"
@@ -12,72 +19,490 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	RedisStandalone = "standalone"
+	RedisSentinel   = "sentinel"
+	RedisCluster    = "cluster"
+)
+
+// RedisRepository bounds every call by the caller's context only; just the
+// connection check in NewRedisRepository has its own 5 second timeout. With a
+// namespace, every key is prefixed by "<namespace>:" so several environments
+// can share one Redis.
+//
+// In cluster mode, keys that are written in one transaction share a hash tag
+// so they map to the same slot: the approved with the rejected duplicates.
+// Each destination index has its own {dest:<id>} tag, so indexes spread over
+// the cluster's slots.
 type RedisRepository struct {
-	client *redis.Client
+	client    redis.UniversalClient
+	namespace string
+	encoding  string
+	batchSize int
+	ttl       TTLPolicy
 }
 
-func NewRedisRepository(host string, port int, db int) (*RedisRepository, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", host, port),
-		DB:       db,
-		PoolSize: 10,
-	})
+func NewRedisRepository(config RedisConfig, ttl TTLPolicy) (*RedisRepository, error) {
+	tlsConfig, err := redisTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var client redis.UniversalClient
+	switch config.Mode {
+	case RedisSentinel:
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.Sentinel.MasterName,
+			SentinelAddrs:    config.Sentinel.Addrs,
+			SentinelUsername: config.Sentinel.Username,
+			SentinelPassword: config.Sentinel.Password,
+			Username:         config.Username,
+			Password:         config.Password,
+			DB:               config.DB,
+			PoolSize:         10,
+			TLSConfig:        tlsConfig,
+		})
+	case RedisCluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     config.Cluster.Addrs,
+			Username:  config.Username,
+			Password:  config.Password,
+			PoolSize:  10,
+			TLSConfig: tlsConfig,
+		})
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:      fmt.Sprintf("%s:%d", config.Host, config.Port),
+			Username:  config.Username,
+			Password:  config.Password,
+			DB:        config.DB,
+			PoolSize:  10,
+			TLSConfig: tlsConfig,
+		})
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisRepository{client: client}, nil
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	return &RedisRepository{
+		client:    client,
+		namespace: config.Namespace,
+		encoding:  config.Encoding,
+		batchSize: batchSize,
+		ttl:       ttl,
+	}, nil
 }
 
-func (r *RedisRepository) StoreHotelByID(hotelID string, hotel *domain.Hotel) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// expire applies ttl to key. A zero ttl removes any expiry left by an earlier
+// policy; EXPIRE with zero would delete the key instead.
+func expire(ctx context.Context, pipe redis.Pipeliner, key string, ttl time.Duration) {
+	if ttl > 0 {
+		pipe.Expire(ctx, key, ttl)
+		return
+	}
+	pipe.Persist(ctx, key)
+}
+
+// encode converts value to a stored record in the repository's encoding.
+func (r *RedisRepository) encode(value interface{}) ([]byte, error) {
+	return encodeAs(r.encoding, value)
+}
 
-	data, err := json.Marshal(hotel)
+// decode reads a stored record in any encoding into target. It reports
+// whether the record should be rewritten: it was migrated to the current
+// schema, or it is stored in another encoding.
+func (r *RedisRepository) decode(data []byte, target interface{}, hotels func(payload interface{}) []interface{}) (bool, error) {
+	migrated, encoding, err := decodeAs(data, target, hotels)
 	if err != nil {
-		return fmt.Errorf("failed to marshal hotel: %w", err)
+		return false, err
 	}
 
-	key := fmt.Sprintf("hotel:id:%s", hotelID)
-	if err := r.client.Set(ctx, key, data, 24*time.Hour).Err(); err != nil {
-		return fmt.Errorf("failed to store hotel: %w", err)
+	return migrated || encoding != r.encoding, nil
+}
+
+func redisTLSConfig(config RedisTLSConfig) (*tls.Config, error) {
+	if !config.Enabled {
+		return nil, nil
 	}
 
-	log.Printf("Stored hotel %s", hotelID)
-	return nil
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Redis CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// redisKeyFormats are the formats of the keys RedisRepository writes, as
+// passed to key. Their first segments cannot be used as a namespace.
+var redisKeyFormats = []string{
+	"{dest:%d}:hotels",
+	"hotel:id:%s",
+	"hotel:dest:%s",
+	"hotel:fingerprint:%s",
+	"hotel:history:%s",
+	"history:destination:%d",
+	"hotels:duplicates:candidates",
+	"hotels:{duplicates}:approved",
+	"hotels:{duplicates}:rejected",
+	"crosswalk:entries",
+	"crosswalk:unmapped",
+	"destinations",
+	"quality:report",
+}
+
+// redisKeyFamily returns the first segment of a key format.
+func redisKeyFamily(format string) string {
+	return strings.SplitN(format, ":", 2)[0]
+}
+
+// key formats a key name and places it in the repository's namespace.
+func (r *RedisRepository) key(format string, args ...interface{}) string {
+	name := fmt.Sprintf(format, args...)
+	if r.namespace == "" {
+		return name
+	}
+	return r.namespace + ":" + name
+}
+
+// destinationIndexKey holds the IDs of a destination's hotels. Each index has
+// its own hash tag, so indexes spread over a cluster's slots.
+func (r *RedisRepository) destinationIndexKey(destinationID int) string {
+	return r.key("{dest:%d}:hotels", destinationID)
+}
+
+// hotelDestinationKey holds the destination a hotel was last indexed in.
+func (r *RedisRepository) hotelDestinationKey(hotelID string) string {
+	return r.key("hotel:dest:%s", hotelID)
+}
+
+// hotelFingerprintKey holds the RecordFingerprint of a stored hotel.
+func (r *RedisRepository) hotelFingerprintKey(hotelID string) string {
+	return r.key("hotel:fingerprint:%s", hotelID)
+}
+
+// historyDestinationKey holds the IDs of the hotels with a version recorded in
+// the destination.
+func (r *RedisRepository) historyDestinationKey(destinationID int) string {
+	return r.key("history:destination:%d", destinationID)
+}
+
+// scanKeys calls fn with every key matching pattern. A cluster is scanned on
+// each master, since SCAN only covers the node it is sent to.
+func (r *RedisRepository) scanKeys(ctx context.Context, pattern string, fn func(key string) error) error {
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, pattern, 500).Iterator()
+		for iter.Next(ctx) {
+			if err := fn(iter.Val()); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return scan(ctx, r.client)
+	}
+
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		// fn is not safe for concurrent use; masters are visited in parallel.
+		mu.Lock()
+		defer mu.Unlock()
+		return scan(ctx, client)
+	})
 }
 
-func (r *RedisRepository) StoreHotelsByDestinationID(destinationID int, hotels []*domain.Hotel) error {
-	if len(hotels) == 0 {
+// StoreHotels writes hotels in pipelines of the configured batch size, then
+// moves each batch to its destinations' indexes and records the fingerprints
+// of the hotels written.
+func (r *RedisRepository) StoreHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+
+	for start := 0; start < len(hotels); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotels) {
+			end = len(hotels)
+		}
+		copy(errs[start:end], r.storeHotelBatch(ctx, hotels[start:end]))
+	}
+
+	stored := 0
+	for _, err := range errs {
+		if err == nil {
+			stored++
+		}
+	}
+
+	log.Printf("Stored %d hotels", stored)
+	return errs
+}
+
+func (r *RedisRepository) storeHotelBatch(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+	cmds := make([]*redis.StatusCmd, len(hotels))
+	pipe := r.client.Pipeline()
+	for i, hotel := range hotels {
+		data, err := r.encode(hotel)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to marshal hotel %s: %w", hotel.HotelID, err)
+			continue
+		}
+		cmds[i] = pipe.Set(ctx, r.key("hotel:id:%s", hotel.HotelID), data, r.ttl.Hotels)
+		// The fingerprint is only set again once the hotel is written, so a
+		// failed write is never mistaken for the stored copy.
+		pipe.Del(ctx, r.hotelFingerprintKey(hotel.HotelID))
+	}
+	// Failures are read from each command below.
+	pipe.Exec(ctx)
+
+	var written []*domain.Hotel
+	var positions []int
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if err := cmd.Err(); err != nil {
+			errs[i] = fmt.Errorf("failed to store hotel %s: %w", hotels[i].HotelID, err)
+			continue
+		}
+		written = append(written, hotels[i])
+		positions = append(positions, i)
+	}
+
+	if len(written) == 0 {
+		return errs
+	}
+
+	if err := r.indexHotels(ctx, written); err != nil {
+		for _, i := range positions {
+			errs[i] = fmt.Errorf("failed to index hotel %s: %w", hotels[i].HotelID, err)
+		}
+	}
+
+	return errs
+}
+
+// indexHotels moves hotels to their destinations' indexes and records their
+// fingerprints. Each index lives in its own slot, so the batch is grouped by
+// destination and every index is changed by a single ZADD or ZREM. Indexes are
+// not updated together with the destination keys: a concurrent move can leave
+// a hotel listed in its old destination, which GetHotelsByDestinationID drops
+// when it reads the record.
+func (r *RedisRepository) indexHotels(ctx context.Context, hotels []*domain.Hotel) error {
+	membershipKeys := make([]string, len(hotels))
+	for i, hotel := range hotels {
+		membershipKeys[i] = r.hotelDestinationKey(hotel.HotelID)
+	}
+	previous, err := r.mget(ctx, membershipKeys)
+	if err != nil {
+		return fmt.Errorf("failed to get hotel destinations: %w", err)
+	}
+
+	added := make(map[int][]*redis.Z)
+	removed := make(map[int][]interface{})
+	for i, hotel := range hotels {
+		if destinationID, ok := indexedDestination(previous[i]); ok && destinationID != hotel.DestinationID {
+			removed[destinationID] = append(removed[destinationID], hotel.HotelID)
+		}
+		added[hotel.DestinationID] = append(added[hotel.DestinationID], &redis.Z{Member: hotel.HotelID})
+	}
+
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for destinationID, members := range added {
+			indexKey := r.destinationIndexKey(destinationID)
+			pipe.ZAdd(ctx, indexKey, members...)
+			expire(ctx, pipe, indexKey, r.ttl.Hotels)
+		}
+		for destinationID, members := range removed {
+			pipe.ZRem(ctx, r.destinationIndexKey(destinationID), members...)
+		}
+		for i, hotel := range hotels {
+			pipe.Set(ctx, membershipKeys[i], hotel.DestinationID, r.ttl.Hotels)
+			pipe.Set(ctx, r.hotelFingerprintKey(hotel.HotelID), hotel.RecordFingerprint(), r.ttl.Hotels)
+		}
 		return nil
+	})
+	return err
+}
+
+// indexedDestination parses a destination key's value as read by MGET.
+func indexedDestination(value interface{}) (int, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, false
 	}
+	destinationID, err := strconv.Atoi(s)
+	return destinationID, err == nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// watch runs fn in a transaction watching keys, retrying a few times when a
+// watched key changes before it commits.
+func (r *RedisRepository) watch(ctx context.Context, fn func(tx *redis.Tx) error, keys ...string) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = r.client.Watch(ctx, fn, keys...)
+		if err != redis.TxFailedErr || attempt == 2 {
+			return err
+		}
+	}
+}
+
+// TouchHotels refreshes the TTL of hotels, their destination and fingerprint
+// keys and their destinations' indexes in one pipeline per batch.
+func (r *RedisRepository) TouchHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
 
-	data, err := json.Marshal(hotels)
+	touch := func(hotels []*domain.Hotel, errs []error) {
+		pipe := r.client.Pipeline()
+		exists := make([]*redis.IntCmd, len(hotels))
+		indexKeys := make(map[string]bool)
+		for i, hotel := range hotels {
+			key := r.key("hotel:id:%s", hotel.HotelID)
+			exists[i] = pipe.Exists(ctx, key)
+			expire(ctx, pipe, key, r.ttl.Hotels)
+			expire(ctx, pipe, r.hotelDestinationKey(hotel.HotelID), r.ttl.Hotels)
+			expire(ctx, pipe, r.hotelFingerprintKey(hotel.HotelID), r.ttl.Hotels)
+			indexKeys[r.destinationIndexKey(hotel.DestinationID)] = true
+		}
+		for indexKey := range indexKeys {
+			expire(ctx, pipe, indexKey, r.ttl.Hotels)
+		}
+		// Failures are read from each command below.
+		pipe.Exec(ctx)
+
+		for i, cmd := range exists {
+			count, err := cmd.Result()
+			switch {
+			case err != nil:
+				errs[i] = fmt.Errorf("failed to touch hotel %s: %w", hotels[i].HotelID, err)
+			case count == 0:
+				errs[i] = domain.ErrHotelNotStored
+			}
+		}
+	}
+
+	for start := 0; start < len(hotels); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotels) {
+			end = len(hotels)
+		}
+		touch(hotels[start:end], errs[start:end])
+	}
+
+	return errs
+}
+
+// DeleteHotelsExcept deletes the stored hotels not listed in hotelIDs along
+// with their place in their destination's index.
+func (r *RedisRepository) DeleteHotelsExcept(ctx context.Context, hotelIDs []string) (int, error) {
+	keep := make(map[string]bool, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		keep[hotelID] = true
+	}
+
+	prefix := r.key("hotel:id:")
+	var stale []string
+	err := r.scanKeys(ctx, prefix+"*", func(key string) error {
+		if hotelID := strings.TrimPrefix(key, prefix); !keep[hotelID] {
+			stale = append(stale, hotelID)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal hotels: %w", err)
+		return 0, fmt.Errorf("failed to scan hotel keys: %w", err)
 	}
 
-	key := fmt.Sprintf("hotels:destination:%d", destinationID)
-	if err := r.client.Set(ctx, key, data, 24*time.Hour).Err(); err != nil {
-		return fmt.Errorf("failed to store hotels by destination: %w", err)
+	if err := r.deleteHotels(ctx, stale); err != nil {
+		return 0, err
 	}
 
-	log.Printf("Stored %d hotels for destination %d", len(hotels), destinationID)
-	return nil
+	log.Printf("Deleted %d hotels no longer supplied", len(stale))
+	return len(stale), nil
 }
 
-func (r *RedisRepository) GetHotelByID(hotelID string) (*domain.Hotel, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// deleteHotels deletes hotels along with their place in their destination's
+// index, then appends a tombstone to each one's history. Tombstones are never
+// trimmed on their own; the next append does.
+func (r *RedisRepository) deleteHotels(ctx context.Context, hotelIDs []string) error {
+	for start := 0; start < len(hotelIDs); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotelIDs) {
+			end = len(hotelIDs)
+		}
+		batch := hotelIDs[start:end]
+
+		membershipKeys := make([]string, len(batch))
+		for i, hotelID := range batch {
+			membershipKeys[i] = r.hotelDestinationKey(hotelID)
+		}
+		destinations, err := r.mget(ctx, membershipKeys)
+		if err != nil {
+			return fmt.Errorf("failed to get hotel destinations: %w", err)
+		}
+
+		removed := make(map[int][]interface{})
+		pipe := r.client.Pipeline()
+		for i, hotelID := range batch {
+			pipe.Del(ctx, r.key("hotel:id:%s", hotelID))
+			pipe.Del(ctx, membershipKeys[i])
+			pipe.Del(ctx, r.hotelFingerprintKey(hotelID))
+			if destinationID, ok := indexedDestination(destinations[i]); ok {
+				removed[destinationID] = append(removed[destinationID], hotelID)
+			}
+		}
+		for destinationID, members := range removed {
+			pipe.ZRem(ctx, r.destinationIndexKey(destinationID), members...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete hotels: %w", err)
+		}
+
+		for _, hotelID := range batch {
+			tombstone := domain.NewTombstone(time.Now())
+			if _, err := r.appendHotelVersion(ctx, hotelID, tombstone, 0); err != nil {
+				return fmt.Errorf("failed to record deletion of hotel %s: %w", hotelID, err)
+			}
+		}
+	}
+
+	return nil
+}
 
-	key := fmt.Sprintf("hotel:id:%s", hotelID)
+func (r *RedisRepository) GetHotelByID(ctx context.Context, hotelID string) (*domain.Hotel, error) {
+	key := r.key("hotel:id:%s", hotelID)
 	data, err := r.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -87,47 +512,82 @@ func (r *RedisRepository) GetHotelByID(hotelID string) (*domain.Hotel, error) {
 	}
 
 	var hotel domain.Hotel
-	if err := json.Unmarshal(data, &hotel); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal hotel: %w", err)
+	if _, err := r.decode(data, &hotel, singleHotel); err != nil {
+		return nil, fmt.Errorf("failed to decode hotel %s: %w", hotelID, err)
 	}
 
 	return &hotel, nil
 }
 
-func (r *RedisRepository) GetHotelsByDestinationID(destinationID int) ([]*domain.Hotel, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// GetHotelsByDestinationID reads one page of IDs from the destination index
+// (members share a score, so they are ordered by ID) and resolves them with a
+// single MGET. Hotels whose records have expired or moved to another
+// destination are skipped.
+func (r *RedisRepository) GetHotelsByDestinationID(ctx context.Context, destinationID int, page domain.Page) ([]*domain.Hotel, int, error) {
+	key := r.destinationIndexKey(destinationID)
+	total, err := r.client.ZCard(ctx, key).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count hotels by destination: %w", err)
+	}
 
-	key := fmt.Sprintf("hotels:destination:%d", destinationID)
-	data, err := r.client.Get(ctx, key).Bytes()
+	start, end := page.Bounds(int(total))
+	if start >= end {
+		return []*domain.Hotel{}, int(total), nil
+	}
+
+	hotelIDs, err := r.client.ZRange(ctx, key, int64(start), int64(end-1)).Result()
 	if err != nil {
-		if err == redis.Nil {
-			return []*domain.Hotel{}, nil
-		}
-		return nil, fmt.Errorf("failed to get hotels by destination: %w", err)
+		return nil, 0, fmt.Errorf("failed to get hotels by destination: %w", err)
 	}
 
-	var hotels []*domain.Hotel
-	if err := json.Unmarshal(data, &hotels); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal hotels: %w", err)
+	keys := make([]string, len(hotelIDs))
+	for i, hotelID := range hotelIDs {
+		keys[i] = r.key("hotel:id:%s", hotelID)
 	}
 
-	return hotels, nil
+	hotels, err := r.getHotelsByKeys(ctx, keys)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get hotels by destination: %w", err)
+	}
+
+	// Hotels are only added to and moved between indexes, so the IDs of
+	// hotels that have expired or moved elsewhere are dropped here as they
+	// are found.
+	found := make(map[string]bool, len(hotels))
+	listed := hotels[:0]
+	for _, hotel := range hotels {
+		if hotel.DestinationID == destinationID {
+			found[hotel.HotelID] = true
+			listed = append(listed, hotel)
+		}
+	}
+	if len(listed) < len(hotelIDs) {
+		var stale []interface{}
+		for _, hotelID := range hotelIDs {
+			if !found[hotelID] {
+				stale = append(stale, hotelID)
+			}
+		}
+		if err := r.client.ZRem(ctx, key, stale...).Err(); err != nil {
+			log.Printf("Failed to drop stale hotels from destination %d: %v", destinationID, err)
+		} else {
+			total -= int64(len(stale))
+		}
+	}
+
+	return listed, int(total), nil
 }
 
-func (r *RedisRepository) GetHotelsByIDRange(hotelIDs []string) ([]*domain.Hotel, error) {
+func (r *RedisRepository) GetHotelsByIDRange(ctx context.Context, hotelIDs []string) ([]*domain.Hotel, error) {
 	if len(hotelIDs) == 0 {
 		return []*domain.Hotel{}, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	pipe := r.client.Pipeline()
 	cmds := make(map[string]*redis.StringCmd)
 
 	for _, hotelID := range hotelIDs {
-		key := fmt.Sprintf("hotel:id:%s", hotelID)
+		key := r.key("hotel:id:%s", hotelID)
 		cmds[hotelID] = pipe.Get(ctx, key)
 	}
 
@@ -148,8 +608,86 @@ func (r *RedisRepository) GetHotelsByIDRange(hotelIDs []string) ([]*domain.Hotel
 		}
 
 		var hotel domain.Hotel
-		if err := json.Unmarshal(data, &hotel); err != nil {
-			log.Printf("Failed to unmarshal hotel %s: %v", hotelID, err)
+		if _, err := r.decode(data, &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", hotelID, err)
+			continue
+		}
+
+		hotels = append(hotels, &hotel)
+	}
+
+	return hotels, nil
+}
+
+// GetHotelFingerprints reads the fingerprint keys with one MGET per batch of
+// the configured size.
+func (r *RedisRepository) GetHotelFingerprints(ctx context.Context, hotelIDs []string) (map[string]string, error) {
+	fingerprints := make(map[string]string, len(hotelIDs))
+
+	for start := 0; start < len(hotelIDs); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotelIDs) {
+			end = len(hotelIDs)
+		}
+
+		keys := make([]string, 0, end-start)
+		for _, hotelID := range hotelIDs[start:end] {
+			keys = append(keys, r.hotelFingerprintKey(hotelID))
+		}
+		values, err := r.mget(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hotel fingerprints: %w", err)
+		}
+		for i, value := range values {
+			if fingerprint, ok := value.(string); ok {
+				fingerprints[hotelIDs[start+i]] = fingerprint
+			}
+		}
+	}
+
+	return fingerprints, nil
+}
+
+func (r *RedisRepository) GetAllHotels(ctx context.Context) ([]*domain.Hotel, error) {
+	var keys []string
+	err := r.scanKeys(ctx, r.key("hotel:id:*"), func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan hotel keys: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return []*domain.Hotel{}, nil
+	}
+
+	hotels, err := r.getHotelsByKeys(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotels: %w", err)
+	}
+
+	return hotels, nil
+}
+
+// getHotelsByKeys resolves hotel keys with one MGET, keeping their order and
+// skipping keys that are missing or fail to decode.
+func (r *RedisRepository) getHotelsByKeys(ctx context.Context, keys []string) ([]*domain.Hotel, error) {
+	values, err := r.mget(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	hotels := make([]*domain.Hotel, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var hotel domain.Hotel
+		if _, err := r.decode([]byte(data), &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", keys[i], err)
 			continue
 		}
 
@@ -159,6 +697,641 @@ func (r *RedisRepository) GetHotelsByIDRange(hotelIDs []string) ([]*domain.Hotel
 	return hotels, nil
 }
 
+// mget reads keys with MGET. Hotel keys are spread over a cluster's slots,
+// where MGET fails across slots, so there each key is read with a pipelined
+// GET that the client routes to its node.
+func (r *RedisRepository) mget(ctx context.Context, keys []string) ([]interface{}, error) {
+	if _, ok := r.client.(*redis.ClusterClient); !ok {
+		return r.client.MGet(ctx, keys...).Result()
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if value, err := cmd.Result(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+func (r *RedisRepository) StoreDuplicateCandidates(ctx context.Context, candidates []domain.DuplicateCandidate) error {
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal duplicate candidates: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.key("hotels:duplicates:candidates"), data, r.ttl.Reports).Err(); err != nil {
+		return fmt.Errorf("failed to store duplicate candidates: %w", err)
+	}
+
+	log.Printf("Stored %d duplicate candidates", len(candidates))
+	return nil
+}
+
+func (r *RedisRepository) GetDuplicateCandidates(ctx context.Context) ([]domain.DuplicateCandidate, error) {
+	data, err := r.client.Get(ctx, r.key("hotels:duplicates:candidates")).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return []domain.DuplicateCandidate{}, nil
+		}
+		return nil, fmt.Errorf("failed to get duplicate candidates: %w", err)
+	}
+
+	var candidates []domain.DuplicateCandidate
+	if err := json.Unmarshal(data, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal duplicate candidates: %w", err)
+	}
+
+	return candidates, nil
+}
+
+func (r *RedisRepository) ApproveDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, r.key("hotels:{duplicates}:approved"), duplicateID, hotelID)
+	pipe.SRem(ctx, r.key("hotels:{duplicates}:rejected"), domain.DuplicatePairKey(hotelID, duplicateID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to approve duplicate: %w", err)
+	}
+
+	if err := r.deleteHotels(ctx, []string{duplicateID}); err != nil {
+		return fmt.Errorf("failed to approve duplicate: %w", err)
+	}
+
+	log.Printf("Approved duplicate %s -> %s", duplicateID, hotelID)
+	return nil
+}
+
+func (r *RedisRepository) RejectDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	if err := r.client.SAdd(ctx, r.key("hotels:{duplicates}:rejected"), domain.DuplicatePairKey(hotelID, duplicateID)).Err(); err != nil {
+		return fmt.Errorf("failed to reject duplicate: %w", err)
+	}
+
+	log.Printf("Rejected duplicate %s / %s", hotelID, duplicateID)
+	return nil
+}
+
+func (r *RedisRepository) GetApprovedDuplicates(ctx context.Context) (domain.HotelIDCrosswalk, error) {
+	approved, err := r.client.HGetAll(ctx, r.key("hotels:{duplicates}:approved")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approved duplicates: %w", err)
+	}
+
+	return domain.HotelIDCrosswalk(approved), nil
+}
+
+func (r *RedisRepository) GetRejectedDuplicates(ctx context.Context) (map[string]bool, error) {
+	members, err := r.client.SMembers(ctx, r.key("hotels:{duplicates}:rejected")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rejected duplicates: %w", err)
+	}
+
+	rejected := make(map[string]bool, len(members))
+	for _, member := range members {
+		rejected[member] = true
+	}
+	return rejected, nil
+}
+
+func (r *RedisRepository) GetCrosswalkEntries(ctx context.Context) ([]domain.CrosswalkEntry, error) {
+	fields, err := r.client.HGetAll(ctx, r.key("crosswalk:entries")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crosswalk entries: %w", err)
+	}
+
+	entries := make([]domain.CrosswalkEntry, 0, len(fields))
+	for field, canonicalID := range fields {
+		parts := strings.SplitN(field, "|", 3)
+		if len(parts) != 3 {
+			log.Printf("Skipping malformed crosswalk field %q", field)
+			continue
+		}
+		entries = append(entries, domain.CrosswalkEntry{
+			Supplier:    parts[0],
+			Kind:        parts[1],
+			NativeID:    parts[2],
+			CanonicalID: canonicalID,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Supplier != b.Supplier {
+			return a.Supplier < b.Supplier
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.NativeID < b.NativeID
+	})
+
+	return entries, nil
+}
+
+func (r *RedisRepository) StoreCrosswalkEntry(ctx context.Context, entry domain.CrosswalkEntry) error {
+	field := fmt.Sprintf("%s|%s|%s", entry.Supplier, entry.Kind, entry.NativeID)
+	if err := r.client.HSet(ctx, r.key("crosswalk:entries"), field, entry.CanonicalID).Err(); err != nil {
+		return fmt.Errorf("failed to store crosswalk entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) DeleteCrosswalkEntry(ctx context.Context, supplier, kind, nativeID string) error {
+	field := fmt.Sprintf("%s|%s|%s", supplier, kind, nativeID)
+	if err := r.client.HDel(ctx, r.key("crosswalk:entries"), field).Err(); err != nil {
+		return fmt.Errorf("failed to delete crosswalk entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) StoreCrosswalkReport(ctx context.Context, report domain.CrosswalkReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal crosswalk report: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.key("crosswalk:unmapped"), data, r.ttl.Reports).Err(); err != nil {
+		return fmt.Errorf("failed to store crosswalk report: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) GetCrosswalkReport(ctx context.Context) (*domain.CrosswalkReport, error) {
+	data, err := r.client.Get(ctx, r.key("crosswalk:unmapped")).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return &domain.CrosswalkReport{Unmapped: map[string]domain.UnmappedIDs{}}, nil
+		}
+		return nil, fmt.Errorf("failed to get crosswalk report: %w", err)
+	}
+
+	var report domain.CrosswalkReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal crosswalk report: %w", err)
+	}
+
+	return &report, nil
+}
+
+func (r *RedisRepository) StoreDestinations(ctx context.Context, destinations []*domain.Destination) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(destinations))
+	for _, destination := range destinations {
+		data, err := json.Marshal(destination)
+		if err != nil {
+			return fmt.Errorf("failed to marshal destination %d: %w", destination.DestinationID, err)
+		}
+		fields[strconv.Itoa(destination.DestinationID)] = data
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, r.key("destinations"))
+	pipe.HSet(ctx, r.key("destinations"), fields)
+	expire(ctx, pipe, r.key("destinations"), r.ttl.Destinations)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store destinations: %w", err)
+	}
+
+	log.Printf("Stored %d destinations", len(destinations))
+	return nil
+}
+
+func (r *RedisRepository) GetDestinationByID(ctx context.Context, destinationID int) (*domain.Destination, error) {
+	data, err := r.client.HGet(ctx, r.key("destinations"), strconv.Itoa(destinationID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("destination not found: %d", destinationID)
+		}
+		return nil, fmt.Errorf("failed to get destination: %w", err)
+	}
+
+	var destination domain.Destination
+	if err := json.Unmarshal(data, &destination); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal destination: %w", err)
+	}
+
+	return &destination, nil
+}
+
+func (r *RedisRepository) GetAllDestinations(ctx context.Context) ([]*domain.Destination, error) {
+	fields, err := r.client.HGetAll(ctx, r.key("destinations")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destinations: %w", err)
+	}
+
+	destinations := make([]*domain.Destination, 0, len(fields))
+	for field, data := range fields {
+		var destination domain.Destination
+		if err := json.Unmarshal([]byte(data), &destination); err != nil {
+			log.Printf("Failed to unmarshal destination %s: %v", field, err)
+			continue
+		}
+		destinations = append(destinations, &destination)
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].DestinationID < destinations[j].DestinationID
+	})
+
+	return destinations, nil
+}
+
+func (r *RedisRepository) StoreQualityReport(ctx context.Context, report domain.QualityReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quality report: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.key("quality:report"), data, r.ttl.Reports).Err(); err != nil {
+		return fmt.Errorf("failed to store quality report: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) GetQualityReport(ctx context.Context) (*domain.QualityReport, error) {
+	data, err := r.client.Get(ctx, r.key("quality:report")).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("quality report not found")
+		}
+		return nil, fmt.Errorf("failed to get quality report: %w", err)
+	}
+
+	var report domain.QualityReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quality report: %w", err)
+	}
+
+	return &report, nil
+}
+
+// AppendHotelVersion watches the hotel's history while it reads the newest
+// version, so a concurrent append makes it retry with the next number. The
+// hotel is added to its destination's history index first, so the index may
+// list a hotel whose append then failed but never misses one.
+func (r *RedisRepository) AppendHotelVersion(ctx context.Context, version domain.HotelVersion, maxVersions int) (int, error) {
+	hotelID := version.Hotel.HotelID
+	version.ExpiresAt = versionExpiry(r.ttl.Hotels)
+	if err := r.client.SAdd(ctx, r.historyDestinationKey(version.Hotel.DestinationID), hotelID).Err(); err != nil {
+		return 0, fmt.Errorf("failed to index hotel version: %w", err)
+	}
+
+	appended, err := r.appendHotelVersion(ctx, hotelID, version, maxVersions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store hotel version: %w", err)
+	}
+	return appended, nil
+}
+
+// appendHotelVersion records version in the hotel's history, keeping at most
+// maxVersions; zero keeps them all.
+func (r *RedisRepository) appendHotelVersion(ctx context.Context, hotelID string, version domain.HotelVersion, maxVersions int) (int, error) {
+	key := r.key("hotel:history:%s", hotelID)
+	appended := 0
+	appendVersion := func(tx *redis.Tx) error {
+		appended = 0
+
+		var latest *domain.HotelVersion
+		data, err := tx.LIndex(ctx, key, 0).Bytes()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if err == nil {
+			latest = &domain.HotelVersion{}
+			if _, err := r.decode(data, latest, versionedHotel); err != nil {
+				return fmt.Errorf("failed to decode latest version: %w", err)
+			}
+		}
+
+		number, refresh := domain.NextHotelVersion(latest, version, time.Now())
+		if number == 0 && !refresh {
+			return nil
+		}
+
+		var refreshed, appendedData []byte
+		if refresh {
+			latest.ExpiresAt = version.ExpiresAt
+			if refreshed, err = r.encode(latest); err != nil {
+				return fmt.Errorf("failed to marshal hotel version: %w", err)
+			}
+		}
+		if number > 0 {
+			version.Version = number
+			if appendedData, err = r.encode(version); err != nil {
+				return fmt.Errorf("failed to marshal hotel version: %w", err)
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if refreshed != nil {
+				pipe.LSet(ctx, key, 0, refreshed)
+			}
+			if appendedData != nil {
+				pipe.LPush(ctx, key, appendedData)
+				if maxVersions > 0 {
+					pipe.LTrim(ctx, key, 0, int64(maxVersions-1))
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			appended = number
+		}
+		return err
+	}
+
+	if err := r.watch(ctx, appendVersion, key); err != nil {
+		return 0, err
+	}
+	return appended, nil
+}
+
+func (r *RedisRepository) GetHotelHistory(ctx context.Context, hotelID string) ([]domain.HotelVersion, error) {
+	key := r.key("hotel:history:%s", hotelID)
+	entries, err := r.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel history: %w", err)
+	}
+
+	history := make([]domain.HotelVersion, 0, len(entries))
+	for _, entry := range entries {
+		var version domain.HotelVersion
+		if _, err := r.decode([]byte(entry), &version, versionedHotel); err != nil {
+			log.Printf("Failed to decode version of hotel %s: %v", hotelID, err)
+			continue
+		}
+		history = append(history, version)
+	}
+
+	return history, nil
+}
+
+func (r *RedisRepository) GetDestinationHistoryHotelIDs(ctx context.Context, destinationID int) ([]string, error) {
+	hotelIDs, err := r.client.SMembers(ctx, r.historyDestinationKey(destinationID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel history IDs: %w", err)
+	}
+
+	return hotelIDs, nil
+}
+
+// MigrateRecords rewrites every stored hotel and history entry that is older
+// than HotelSchemaVersion or stored in another encoding, keeping the remaining
+// TTL, moves reviewed duplicates to their hash-tagged keys, indexes history by
+// destination and deletes destination indexes in older layouts. Records that fail to decode are
+// counted and left as is.
+func (r *RedisRepository) MigrateRecords(ctx context.Context) (MigrationReport, error) {
+	var report MigrationReport
+
+	err := r.scanKeys(ctx, r.key("hotel:id:*"), func(key string) error {
+		report.Scanned++
+
+		data, err := r.client.Get(ctx, key).Bytes()
+		if err != nil {
+			if err != redis.Nil {
+				log.Printf("Failed to read %s: %v", key, err)
+				report.Failed++
+			}
+			return nil
+		}
+
+		var hotel domain.Hotel
+		migrated, err := r.decode(data, &hotel, singleHotel)
+		if err != nil {
+			log.Printf("Failed to decode %s: %v", key, err)
+			report.Failed++
+			return nil
+		}
+		if !migrated {
+			return nil
+		}
+
+		encoded, err := r.encode(&hotel)
+		if err != nil {
+			log.Printf("Failed to encode %s: %v", key, err)
+			report.Failed++
+			return nil
+		}
+		if err := r.client.Set(ctx, key, encoded, redis.KeepTTL).Err(); err != nil {
+			log.Printf("Failed to rewrite %s: %v", key, err)
+			report.Failed++
+			return nil
+		}
+		report.Migrated++
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to scan hotel:id:*: %w", err)
+	}
+
+	// Reviewed duplicates have no TTL, so they are renamed rather than
+	// dropped. An existing new key wins over the legacy one.
+	renames := [][2]string{
+		{r.key("hotels:duplicates:approved"), r.key("hotels:{duplicates}:approved")},
+		{r.key("hotels:duplicates:rejected"), r.key("hotels:{duplicates}:rejected")},
+	}
+	for _, rename := range renames {
+		exists, err := r.client.Exists(ctx, rename[0]).Result()
+		if err != nil {
+			return report, fmt.Errorf("failed to check %s: %w", rename[0], err)
+		}
+		if exists == 0 {
+			continue
+		}
+		report.Scanned++
+
+		renamed, err := r.client.RenameNX(ctx, rename[0], rename[1]).Result()
+		if err != nil {
+			log.Printf("Failed to rename %s: %v", rename[0], err)
+			report.Failed++
+			continue
+		}
+		if !renamed {
+			log.Printf("Left %s as is: %s already exists", rename[0], rename[1])
+			report.Failed++
+			continue
+		}
+		report.Migrated++
+	}
+
+	// Destination lists used to be stored as one blob per destination, and
+	// the sorted set indexes first had no hash tag, then one tag shared by
+	// every index. All are rebuilt by the next fetch and can be dropped.
+	for _, pattern := range []string{"hotels:destination:*", "destination:hotels:*", "hotel:destination:*", "{destination-index}:*"} {
+		err := r.scanKeys(ctx, r.key(pattern), func(key string) error {
+			report.Scanned++
+
+			if err := r.client.Del(ctx, key).Err(); err != nil {
+				log.Printf("Failed to delete %s: %v", key, err)
+				report.Failed++
+				return nil
+			}
+			report.Migrated++
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to scan %s: %w", pattern, err)
+		}
+	}
+
+	err = r.scanKeys(ctx, r.key("hotel:history:*"), func(key string) error {
+		migrated := r.migrateHistory(ctx, key)
+		report.Scanned += migrated.Scanned
+		report.Migrated += migrated.Migrated
+		report.Failed += migrated.Failed
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to scan hotel history keys: %w", err)
+	}
+
+	return report, nil
+}
+
+// migrateHistory rewrites the entries of one history list that need it, and
+// adds the hotel to the destination index of each version. The list is
+// replaced in one transaction watching it, so a version appended meanwhile
+// makes it read the list again rather than be lost or overwritten. Entries
+// that fail to decode are kept as they are.
+func (r *RedisRepository) migrateHistory(ctx context.Context, key string) MigrationReport {
+	var report MigrationReport
+	var destinations map[int]bool
+	rewrite := func(tx *redis.Tx) error {
+		report = MigrationReport{}
+		destinations = make(map[int]bool)
+
+		entries, err := tx.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+
+		rewritten := make([]interface{}, len(entries))
+		for i, entry := range entries {
+			report.Scanned++
+			rewritten[i] = entry
+
+			var version domain.HotelVersion
+			migrated, err := r.decode([]byte(entry), &version, versionedHotel)
+			if err != nil {
+				log.Printf("Failed to decode %s[%d]: %v", key, i, err)
+				report.Failed++
+				continue
+			}
+			if version.Hotel != nil {
+				destinations[version.Hotel.DestinationID] = true
+			}
+			if !migrated {
+				continue
+			}
+
+			encoded, err := r.encode(version)
+			if err != nil {
+				log.Printf("Failed to encode %s[%d]: %v", key, i, err)
+				report.Failed++
+				continue
+			}
+			rewritten[i] = encoded
+			report.Migrated++
+		}
+
+		if report.Migrated == 0 {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.RPush(ctx, key, rewritten...)
+			return nil
+		})
+		return err
+	}
+
+	if err := r.watch(ctx, rewrite, key); err != nil {
+		log.Printf("Failed to rewrite %s: %v", key, err)
+		report.Failed += report.Migrated
+		report.Migrated = 0
+	}
+
+	// History recorded before the destination index existed is added to it
+	// here.
+	hotelID := strings.TrimPrefix(key, r.key("hotel:history:"))
+	for destinationID := range destinations {
+		if err := r.client.SAdd(ctx, r.historyDestinationKey(destinationID), hotelID).Err(); err != nil {
+			log.Printf("Failed to index %s in destination %d: %v", key, destinationID, err)
+			report.Failed++
+		}
+	}
+
+	return report
+}
+
+// NamespaceKeys returns every key in the repository's namespace. It requires a
+// namespace, since otherwise it would list the whole database.
+func (r *RedisRepository) NamespaceKeys(ctx context.Context) ([]string, error) {
+	if r.namespace == "" {
+		return nil, fmt.Errorf("no Redis namespace configured")
+	}
+
+	var keys []string
+	err := r.scanKeys(ctx, r.key("*"), func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan namespace %s: %w", r.namespace, err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// PurgeNamespace deletes every key in the repository's namespace and returns
+// how many were deleted. Keys are deleted one per command, in pipelines, so
+// the purge works across cluster slots.
+func (r *RedisRepository) PurgeNamespace(ctx context.Context) (int, error) {
+	keys, err := r.NamespaceKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for start := 0; start < len(keys); start += 500 {
+		end := start + 500
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		pipe := r.client.Pipeline()
+		cmds := make([]*redis.IntCmd, 0, end-start)
+		for _, key := range keys[start:end] {
+			cmds = append(cmds, pipe.Unlink(ctx, key))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return deleted, fmt.Errorf("failed to purge namespace %s: %w", r.namespace, err)
+		}
+		for _, cmd := range cmds {
+			deleted += int(cmd.Val())
+		}
+	}
+
+	log.Printf("Purged %d keys from namespace %s", deleted, r.namespace)
+	return deleted, nil
+}
+
 func (r *RedisRepository) Close() error {
 	return r.client.Close()
 }
@@ -0,0 +1,186 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hotelsdatapipeline/domain"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisMigrateHistory(t *testing.T) {
+	repo := newRedisTestRepository(t, TTLPolicy{})
+	ctx := context.Background()
+
+	current, err := encodeRecord(domain.HotelVersion{Version: 2, Fingerprint: "b",
+		Hotel: &domain.Hotel{HotelID: "h1", DestinationID: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bare version 1 record, written before the envelope and policies.
+	legacy := `{"version":1,"fingerprint":"a","hotel":{"id":"h1","destination_id":4,"booking_conditions":["No pets allowed"]}}`
+
+	key := repo.key("hotel:history:%s", "h1")
+	if err := repo.client.RPush(ctx, key, current, legacy, "not a record").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := repo.MigrateRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Migrated != 1 || report.Failed != 1 {
+		t.Errorf("report = %+v, want 1 migrated and 1 failed", report)
+	}
+
+	entries, err := repo.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0] != string(current) || entries[2] != "not a record" {
+		t.Fatalf("history not rewritten in place: %q", entries)
+	}
+	var envelope recordEnvelope
+	if err := json.Unmarshal([]byte(entries[1]), &envelope); err != nil || envelope.SchemaVersion != HotelSchemaVersion {
+		t.Errorf("legacy entry not migrated: %s", entries[1])
+	}
+
+	for _, destinationID := range []int{4, 5} {
+		hotelIDs, err := repo.GetDestinationHistoryHotelIDs(ctx, destinationID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hotelIDs) != 1 || hotelIDs[0] != "h1" {
+			t.Errorf("destination %d history hotels = %v, want [h1]", destinationID, hotelIDs)
+		}
+	}
+}
+
+func TestRedisDestinationIndexes(t *testing.T) {
+	repo := newRedisTestRepository(t, TTLPolicy{})
+	ctx := context.Background()
+
+	storeTestHotels(t, repo,
+		&domain.Hotel{HotelID: "a", DestinationID: 1},
+		&domain.Hotel{HotelID: "b", DestinationID: 2},
+		&domain.Hotel{HotelID: "c", DestinationID: 2},
+	)
+
+	// Each destination is indexed under its own hash tag.
+	for key, want := range map[string]int64{
+		repo.key("{dest:1}:hotels"): 1,
+		repo.key("{dest:2}:hotels"): 2,
+	} {
+		if count, err := repo.client.ZCard(ctx, key).Result(); err != nil || count != want {
+			t.Errorf("ZCARD %s = %d, %v; want %d", key, count, err, want)
+		}
+	}
+
+	// An entry left behind by a concurrent move is dropped when read.
+	if err := repo.client.ZAdd(ctx, repo.destinationIndexKey(1), &redis.Z{Member: "b"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	hotels, total, err := repo.GetHotelsByDestinationID(ctx, 1, domain.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hotelIDs(hotels); got != "[a]" || total != 1 {
+		t.Errorf("destination 1 = %s (total %d), want [a] (total 1)", got, total)
+	}
+	if listed, err := repo.client.ZScore(ctx, repo.destinationIndexKey(1), "b").Result(); err != redis.Nil {
+		t.Errorf("stale entry kept in destination 1 index: %v, %v", listed, err)
+	}
+}
+
+func TestRedisMigrateDropsSharedTagIndexes(t *testing.T) {
+	repo := newRedisTestRepository(t, TTLPolicy{})
+	ctx := context.Background()
+
+	legacy := []string{
+		repo.key("{destination-index}:destination:1"),
+		repo.key("{destination-index}:hotel:a"),
+	}
+	if err := repo.client.ZAdd(ctx, legacy[0], &redis.Z{Member: "a"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.client.Set(ctx, legacy[1], 1, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.MigrateRecords(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := repo.client.Exists(ctx, legacy...).Result(); err != nil || count != 0 {
+		t.Errorf("%d legacy index keys left, err %v", count, err)
+	}
+}
+
+func TestRedisKeyFamiliesReserved(t *testing.T) {
+	for _, format := range redisKeyFormats {
+		config := RedisConfig{Mode: RedisStandalone, Host: "localhost", Port: 6379,
+			Encoding: EncodingJSON, Namespace: redisKeyFamily(format)}
+		if err := config.validate(); err == nil {
+			t.Errorf("namespace %q of key %q accepted", config.Namespace, format)
+		}
+	}
+}
+
+// TestRedisKeyFormatsListed checks that every key format passed to key in
+// redis.go is listed in redisKeyFormats, and so reserved from namespaces.
+func TestRedisKeyFormatsListed(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "redis.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed := make(map[string]bool)
+	for _, format := range redisKeyFormats {
+		listed[format] = true
+	}
+	// Keys written by older versions, which MigrateRecords renames.
+	legacy := map[string]bool{"hotels:duplicates:approved": true, "hotels:duplicates:rejected": true}
+
+	found := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		literal, isLiteral := call.Args[0].(*ast.BasicLit)
+		if !ok || selector.Sel.Name != "key" || !isLiteral {
+			return true
+		}
+		format, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found++
+
+		// Scan patterns and prefixes only need a listed format to match.
+		if strings.ContainsRune(format, '*') || strings.HasSuffix(format, ":") {
+			prefix := strings.SplitN(format, "*", 2)[0]
+			for listedFormat := range listed {
+				if strings.HasPrefix(listedFormat, prefix) {
+					return true
+				}
+			}
+			t.Errorf("no listed key format matches %q", format)
+			return true
+		}
+		if !listed[format] && !legacy[format] {
+			t.Errorf("key format %q is not in redisKeyFormats", format)
+		}
+		return true
+	})
+	if found == 0 {
+		t.Fatal("no key formats found in redis.go")
+	}
+}
@@ -0,0 +1,159 @@
+package infra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"hotelsdatapipeline/domain"
+)
+
+// HotelSchemaVersion is written into the envelope of every stored record that
+// carries hotels. Bump it together with a migration registered for the
+// previous version.
+const HotelSchemaVersion = 2
+
+type recordEnvelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// HotelMigration rewrites the JSON object of one stored hotel from the version
+// it is registered for to the next one.
+type HotelMigration func(hotel map[string]interface{}) error
+
+type MigrationReport struct {
+	Scanned  int `json:"scanned"`
+	Migrated int `json:"migrated"`
+	Failed   int `json:"failed"`
+}
+
+var hotelMigrations = make(map[int]HotelMigration)
+
+func init() {
+	RegisterHotelMigration(1, migrateHotelV1)
+}
+
+func RegisterHotelMigration(fromVersion int, migration HotelMigration) {
+	hotelMigrations[fromVersion] = migration
+}
+
+// migrateHotelV1 upgrades the bare records written before the envelope
+// existed. They predate structured policies, so those are derived from the
+// stored booking conditions.
+func migrateHotelV1(hotel map[string]interface{}) error {
+	if _, ok := hotel["policies"]; ok {
+		return nil
+	}
+
+	var conditions []string
+	if list, ok := hotel["booking_conditions"].([]interface{}); ok {
+		for _, item := range list {
+			if condition, ok := item.(string); ok {
+				conditions = append(conditions, condition)
+			}
+		}
+	}
+
+	data, err := json.Marshal(domain.ExtractPolicies(conditions))
+	if err != nil {
+		return fmt.Errorf("failed to marshal policies: %w", err)
+	}
+
+	var policies map[string]interface{}
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return fmt.Errorf("failed to unmarshal policies: %w", err)
+	}
+
+	hotel["policies"] = policies
+	return nil
+}
+
+func encodeRecord(value interface{}) ([]byte, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(recordEnvelope{SchemaVersion: HotelSchemaVersion, Data: payload})
+}
+
+// openRecord returns the payload of a stored record and its schema version.
+// Records without an envelope predate versioning and count as version 1.
+func openRecord(data []byte) (json.RawMessage, int, error) {
+	var envelope recordEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.SchemaVersion == 0 || len(envelope.Data) == 0 {
+		return data, 1, nil
+	}
+
+	if envelope.SchemaVersion > HotelSchemaVersion {
+		return nil, 0, fmt.Errorf("record schema version %d is newer than supported version %d", envelope.SchemaVersion, HotelSchemaVersion)
+	}
+
+	return envelope.Data, envelope.SchemaVersion, nil
+}
+
+// decodeRecord unwraps data into target. When the record is older than
+// HotelSchemaVersion, every hotel object that hotels locates in the payload is
+// migrated first. It reports whether a migration was applied.
+func decodeRecord(data []byte, target interface{}, hotels func(payload interface{}) []interface{}) (bool, error) {
+	payload, version, err := openRecord(data)
+	if err != nil {
+		return false, err
+	}
+
+	migrated := version < HotelSchemaVersion
+	if migrated {
+		// Numbers are kept as written, so large integers survive.
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.UseNumber()
+		var generic interface{}
+		if err := decoder.Decode(&generic); err != nil {
+			return false, err
+		}
+
+		for _, hotel := range hotels(generic) {
+			fields, ok := hotel.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := migrateHotel(fields, version); err != nil {
+				return false, err
+			}
+		}
+
+		if payload, err = json.Marshal(generic); err != nil {
+			return false, err
+		}
+	}
+
+	if err := json.Unmarshal(payload, target); err != nil {
+		return false, err
+	}
+
+	return migrated, nil
+}
+
+func migrateHotel(hotel map[string]interface{}, version int) error {
+	for ; version < HotelSchemaVersion; version++ {
+		migration, ok := hotelMigrations[version]
+		if !ok {
+			return fmt.Errorf("no migration registered from schema version %d", version)
+		}
+		if err := migration(hotel); err != nil {
+			return fmt.Errorf("failed to migrate from schema version %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func singleHotel(payload interface{}) []interface{} {
+	return []interface{}{payload}
+}
+
+func versionedHotel(payload interface{}) []interface{} {
+	fields, ok := payload.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return []interface{}{fields["hotel"]}
+}
@@ -0,0 +1,1012 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hotelsdatapipeline/domain"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations are applied in order on open; the index of each entry plus
+// one is its version in schema_migrations. Append new entries, never edit
+// applied ones.
+var sqliteMigrations = []string{
+	`CREATE TABLE hotels (
+		hotel_id       TEXT PRIMARY KEY,
+		destination_id INTEGER NOT NULL,
+		name           TEXT NOT NULL,
+		country        TEXT NOT NULL,
+		data           BLOB NOT NULL,
+		expires_at     INTEGER NOT NULL
+	);
+	CREATE INDEX idx_hotels_destination ON hotels (destination_id, expires_at);
+	CREATE INDEX idx_hotels_expires ON hotels (expires_at);
+
+	CREATE TABLE hotel_amenities (
+		hotel_id TEXT NOT NULL REFERENCES hotels (hotel_id) ON DELETE CASCADE,
+		kind     TEXT NOT NULL,
+		amenity  TEXT NOT NULL,
+		PRIMARY KEY (hotel_id, kind, amenity)
+	);
+	CREATE INDEX idx_hotel_amenities_amenity ON hotel_amenities (amenity);
+
+	CREATE TABLE destinations (
+		destination_id INTEGER PRIMARY KEY,
+		data           BLOB NOT NULL,
+		expires_at     INTEGER NOT NULL
+	);
+
+	CREATE TABLE hotel_history (
+		hotel_id    TEXT NOT NULL,
+		version     INTEGER NOT NULL,
+		recorded_at INTEGER NOT NULL,
+		data        BLOB NOT NULL,
+		PRIMARY KEY (hotel_id, version)
+	);
+
+	CREATE TABLE approved_duplicates (
+		duplicate_id TEXT PRIMARY KEY,
+		hotel_id     TEXT NOT NULL
+	);
+
+	CREATE TABLE rejected_duplicates (
+		pair_key TEXT PRIMARY KEY
+	);
+
+	CREATE TABLE crosswalk_entries (
+		supplier     TEXT NOT NULL,
+		kind         TEXT NOT NULL,
+		native_id    TEXT NOT NULL,
+		canonical_id TEXT NOT NULL,
+		PRIMARY KEY (supplier, kind, native_id)
+	);
+
+	CREATE TABLE reports (
+		name       TEXT PRIMARY KEY,
+		data       BLOB NOT NULL,
+		expires_at INTEGER NOT NULL
+	);`,
+	`ALTER TABLE hotel_history ADD COLUMN destination_id INTEGER;
+	UPDATE hotel_history SET destination_id = COALESCE(
+		json_extract(CAST(data AS TEXT), '$.data.hotel.destination_id'),
+		json_extract(CAST(data AS TEXT), '$.hotel.destination_id'))
+	WHERE json_valid(CAST(data AS TEXT));
+	CREATE INDEX idx_hotel_history_destination ON hotel_history (destination_id, hotel_id);`,
+	`ALTER TABLE hotels ADD COLUMN fingerprint TEXT;`,
+}
+
+const (
+	reportDuplicateCandidates = "duplicate_candidates"
+	reportCrosswalk           = "crosswalk_unmapped"
+	reportQuality             = "quality"
+)
+
+// SQLiteRepository stores hotels in an embedded SQLite database. Hotels and
+// history versions keep their versioned JSON in a data column next to the
+// indexed columns queries filter on; expired rows are ignored on read and
+// purged on open.
+type SQLiteRepository struct {
+	db        *sql.DB
+	ttl       TTLPolicy
+	batchSize int
+}
+
+func NewSQLiteRepository(config SQLiteStorageConfig, ttl TTLPolicy) (*SQLiteRepository, error) {
+	path := config.Path
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create SQLite directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to SQLite database: %w", err)
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	r := &SQLiteRepository{db: db, ttl: ttl, batchSize: batchSize}
+	if err := r.migrateSchema(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := r.purgeExpired(ctx); err != nil {
+		log.Printf("Failed to purge expired SQLite rows: %v", err)
+	}
+
+	return r, nil
+}
+
+func (r *SQLiteRepository) migrateSchema(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if current > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", current, len(sqliteMigrations))
+	}
+
+	for version := current + 1; version <= len(sqliteMigrations); version++ {
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, sqliteMigrations[version-1]); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().Unix())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply SQLite migration %d: %w", version, err)
+		}
+		log.Printf("Applied SQLite migration %d", version)
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) purgeExpired(ctx context.Context) error {
+	now := time.Now().Unix()
+	for _, table := range []string{"hotels", "destinations", "reports"} {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at <= ?`, now); err != nil {
+			return fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) expiresAt(ttl time.Duration) int64 {
+	return expiryAfter(ttl).Unix()
+}
+
+func (r *SQLiteRepository) upsertHotel(ctx context.Context, tx *sql.Tx, hotel *domain.Hotel) error {
+	data, err := encodeRecord(hotel)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hotel: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO hotels (hotel_id, destination_id, name, country, data, fingerprint, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (hotel_id) DO UPDATE SET
+			destination_id = excluded.destination_id,
+			name = excluded.name,
+			country = excluded.country,
+			data = excluded.data,
+			fingerprint = excluded.fingerprint,
+			expires_at = excluded.expires_at`,
+		hotel.HotelID, hotel.DestinationID, hotel.HotelName, hotel.Location.Country, data, hotel.RecordFingerprint(),
+		r.expiresAt(r.ttl.Hotels)); err != nil {
+		return fmt.Errorf("failed to store hotel: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM hotel_amenities WHERE hotel_id = ?`, hotel.HotelID); err != nil {
+		return fmt.Errorf("failed to clear hotel amenities: %w", err)
+	}
+	for kind, amenities := range map[string][]string{"general": hotel.Amenities.General, "room": hotel.Amenities.Room} {
+		for _, amenity := range amenities {
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO hotel_amenities (hotel_id, kind, amenity) VALUES (?, ?, ?)`,
+				hotel.HotelID, kind, strings.ToLower(amenity)); err != nil {
+				return fmt.Errorf("failed to store hotel amenity: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// StoreHotels upserts hotels in one transaction per batch of the configured
+// size. Each hotel is written under a savepoint, so a failed hotel is rolled
+// back without losing the rest of its batch.
+func (r *SQLiteRepository) StoreHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+
+	stored := 0
+	for start := 0; start < len(hotels); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotels) {
+			end = len(hotels)
+		}
+
+		batchErrs := make([]error, end-start)
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			for i, hotel := range hotels[start:end] {
+				if _, err := tx.ExecContext(ctx, `SAVEPOINT hotel`); err != nil {
+					return err
+				}
+				if err := r.upsertHotel(ctx, tx, hotel); err != nil {
+					batchErrs[i] = err
+					if _, err := tx.ExecContext(ctx, `ROLLBACK TO hotel`); err != nil {
+						return err
+					}
+				}
+				if _, err := tx.ExecContext(ctx, `RELEASE hotel`); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		for i, hotel := range hotels[start:end] {
+			switch {
+			case err != nil:
+				errs[start+i] = fmt.Errorf("failed to store hotel %s: %w", hotel.HotelID, err)
+			case batchErrs[i] != nil:
+				errs[start+i] = fmt.Errorf("failed to store hotel %s: %w", hotel.HotelID, batchErrs[i])
+			default:
+				stored++
+			}
+		}
+	}
+
+	log.Printf("Stored %d hotels", stored)
+	return errs
+}
+
+// TouchHotels moves the expiry of stored hotels forward, one transaction per
+// batch of the configured size.
+func (r *SQLiteRepository) TouchHotels(ctx context.Context, hotels []*domain.Hotel) []error {
+	errs := make([]error, len(hotels))
+
+	for start := 0; start < len(hotels); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotels) {
+			end = len(hotels)
+		}
+
+		now := time.Now().Unix()
+		expiresAt := r.expiresAt(r.ttl.Hotels)
+		touched := make([]bool, end-start)
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			for i, hotel := range hotels[start:end] {
+				result, err := tx.ExecContext(ctx, `UPDATE hotels SET expires_at = ? WHERE hotel_id = ? AND expires_at > ?`,
+					expiresAt, hotel.HotelID, now)
+				if err != nil {
+					return err
+				}
+				rows, err := result.RowsAffected()
+				if err != nil {
+					return err
+				}
+				touched[i] = rows > 0
+			}
+			return nil
+		})
+
+		for i, hotel := range hotels[start:end] {
+			switch {
+			case err != nil:
+				errs[start+i] = fmt.Errorf("failed to touch hotel %s: %w", hotel.HotelID, err)
+			case !touched[i]:
+				errs[start+i] = domain.ErrHotelNotStored
+			}
+		}
+	}
+
+	return errs
+}
+
+// DeleteHotelsExcept deletes the stored hotels not listed in hotelIDs; their
+// amenities go with them through the foreign key.
+func (r *SQLiteRepository) DeleteHotelsExcept(ctx context.Context, hotelIDs []string) (int, error) {
+	keep := make(map[string]bool, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		keep[hotelID] = true
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT hotel_id FROM hotels`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list hotels: %w", err)
+	}
+	var stale []interface{}
+	for rows.Next() {
+		var hotelID string
+		if err := rows.Scan(&hotelID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to list hotels: %w", err)
+		}
+		if !keep[hotelID] {
+			stale = append(stale, hotelID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list hotels: %w", err)
+	}
+
+	deleted := 0
+	for start := 0; start < len(stale); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(stale) {
+			end = len(stale)
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", end-start), ",")
+		err := r.withTx(ctx, func(tx *sql.Tx) error {
+			result, err := tx.ExecContext(ctx, `DELETE FROM hotels WHERE hotel_id IN (`+placeholders+`)`, stale[start:end]...)
+			if err != nil {
+				return err
+			}
+			count, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			for _, hotelID := range stale[start:end] {
+				if err := r.buryHotel(ctx, tx, hotelID.(string)); err != nil {
+					return err
+				}
+			}
+			deleted += int(count)
+			return nil
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete hotels: %w", err)
+		}
+	}
+
+	log.Printf("Deleted %d hotels no longer supplied", deleted)
+	return deleted, nil
+}
+
+func (r *SQLiteRepository) GetHotelByID(ctx context.Context, hotelID string) (*domain.Hotel, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM hotels WHERE hotel_id = ? AND expires_at > ?`,
+		hotelID, time.Now().Unix()).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("hotel not found: %s", hotelID)
+		}
+		return nil, fmt.Errorf("failed to get hotel: %w", err)
+	}
+
+	var hotel domain.Hotel
+	if _, err := decodeRecord(data, &hotel, singleHotel); err != nil {
+		return nil, fmt.Errorf("failed to decode hotel %s: %w", hotelID, err)
+	}
+
+	return &hotel, nil
+}
+
+func (r *SQLiteRepository) GetHotelsByDestinationID(ctx context.Context, destinationID int, page domain.Page) ([]*domain.Hotel, int, error) {
+	now := time.Now().Unix()
+
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM hotels WHERE destination_id = ? AND expires_at > ?`,
+		destinationID, now).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count hotels by destination: %w", err)
+	}
+
+	start, end := page.Bounds(total)
+	if start >= end {
+		return []*domain.Hotel{}, total, nil
+	}
+
+	hotels, err := r.queryHotels(ctx, `SELECT hotel_id, data FROM hotels WHERE destination_id = ? AND expires_at > ?
+		ORDER BY hotel_id LIMIT ? OFFSET ?`, destinationID, now, end-start, start)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get hotels by destination: %w", err)
+	}
+	return hotels, total, nil
+}
+
+func (r *SQLiteRepository) GetHotelsByIDRange(ctx context.Context, hotelIDs []string) ([]*domain.Hotel, error) {
+	if len(hotelIDs) == 0 {
+		return []*domain.Hotel{}, nil
+	}
+
+	args := make([]interface{}, 0, len(hotelIDs)+1)
+	for _, hotelID := range hotelIDs {
+		args = append(args, hotelID)
+	}
+	args = append(args, time.Now().Unix())
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(hotelIDs)), ",")
+	hotels, err := r.queryHotels(ctx, `SELECT hotel_id, data FROM hotels WHERE hotel_id IN (`+placeholders+`) AND expires_at > ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotels: %w", err)
+	}
+
+	if len(hotels) < len(hotelIDs) {
+		found := make(map[string]bool, len(hotels))
+		for _, hotel := range hotels {
+			found[hotel.HotelID] = true
+		}
+		for _, hotelID := range hotelIDs {
+			if !found[hotelID] {
+				log.Printf("Hotel not found: %s", hotelID)
+			}
+		}
+	}
+
+	return hotels, nil
+}
+
+// GetHotelFingerprints queries the fingerprint column in batches of the
+// configured size.
+func (r *SQLiteRepository) GetHotelFingerprints(ctx context.Context, hotelIDs []string) (map[string]string, error) {
+	fingerprints := make(map[string]string, len(hotelIDs))
+	now := time.Now().Unix()
+
+	for start := 0; start < len(hotelIDs); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(hotelIDs) {
+			end = len(hotelIDs)
+		}
+
+		args := make([]interface{}, 0, end-start+1)
+		for _, hotelID := range hotelIDs[start:end] {
+			args = append(args, hotelID)
+		}
+		args = append(args, now)
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", end-start), ",")
+		rows, err := r.db.QueryContext(ctx, `SELECT hotel_id, fingerprint FROM hotels
+			WHERE hotel_id IN (`+placeholders+`) AND expires_at > ? AND fingerprint IS NOT NULL`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hotel fingerprints: %w", err)
+		}
+		for rows.Next() {
+			var hotelID, fingerprint string
+			if err := rows.Scan(&hotelID, &fingerprint); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan hotel fingerprint: %w", err)
+			}
+			fingerprints[hotelID] = fingerprint
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hotel fingerprints: %w", err)
+		}
+	}
+
+	return fingerprints, nil
+}
+
+func (r *SQLiteRepository) GetAllHotels(ctx context.Context) ([]*domain.Hotel, error) {
+	hotels, err := r.queryHotels(ctx, `SELECT hotel_id, data FROM hotels WHERE expires_at > ?`, time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotels: %w", err)
+	}
+	return hotels, nil
+}
+
+func (r *SQLiteRepository) queryHotels(ctx context.Context, query string, args ...interface{}) ([]*domain.Hotel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hotels := []*domain.Hotel{}
+	for rows.Next() {
+		var hotelID string
+		var data []byte
+		if err := rows.Scan(&hotelID, &data); err != nil {
+			return nil, err
+		}
+
+		var hotel domain.Hotel
+		if _, err := decodeRecord(data, &hotel, singleHotel); err != nil {
+			log.Printf("Failed to decode hotel %s: %v", hotelID, err)
+			continue
+		}
+		hotels = append(hotels, &hotel)
+	}
+
+	return hotels, rows.Err()
+}
+
+func (r *SQLiteRepository) storeReport(ctx context.Context, name string, report interface{}) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO reports (name, data, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at`,
+		name, data, r.expiresAt(r.ttl.Reports))
+	return err
+}
+
+// getReport decodes the named report into target and reports whether a live
+// one was found.
+func (r *SQLiteRepository) getReport(ctx context.Context, name string, target interface{}) (bool, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM reports WHERE name = ? AND expires_at > ?`,
+		name, time.Now().Unix()).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *SQLiteRepository) StoreDuplicateCandidates(ctx context.Context, candidates []domain.DuplicateCandidate) error {
+	if err := r.storeReport(ctx, reportDuplicateCandidates, candidates); err != nil {
+		return fmt.Errorf("failed to store duplicate candidates: %w", err)
+	}
+
+	log.Printf("Stored %d duplicate candidates", len(candidates))
+	return nil
+}
+
+func (r *SQLiteRepository) GetDuplicateCandidates(ctx context.Context) ([]domain.DuplicateCandidate, error) {
+	candidates := []domain.DuplicateCandidate{}
+	if _, err := r.getReport(ctx, reportDuplicateCandidates, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to get duplicate candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+func (r *SQLiteRepository) ApproveDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO approved_duplicates (duplicate_id, hotel_id) VALUES (?, ?)
+			ON CONFLICT (duplicate_id) DO UPDATE SET hotel_id = excluded.hotel_id`, duplicateID, hotelID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM rejected_duplicates WHERE pair_key = ?`, domain.DuplicatePairKey(hotelID, duplicateID)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM hotels WHERE hotel_id = ?`, duplicateID); err != nil {
+			return err
+		}
+		return r.buryHotel(ctx, tx, duplicateID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to approve duplicate: %w", err)
+	}
+
+	log.Printf("Approved duplicate %s -> %s", duplicateID, hotelID)
+	return nil
+}
+
+func (r *SQLiteRepository) RejectDuplicate(ctx context.Context, hotelID, duplicateID string) error {
+	if _, err := r.db.ExecContext(ctx, `INSERT OR IGNORE INTO rejected_duplicates (pair_key) VALUES (?)`,
+		domain.DuplicatePairKey(hotelID, duplicateID)); err != nil {
+		return fmt.Errorf("failed to reject duplicate: %w", err)
+	}
+
+	log.Printf("Rejected duplicate %s / %s", hotelID, duplicateID)
+	return nil
+}
+
+func (r *SQLiteRepository) GetApprovedDuplicates(ctx context.Context) (domain.HotelIDCrosswalk, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT duplicate_id, hotel_id FROM approved_duplicates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approved duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	approved := make(domain.HotelIDCrosswalk)
+	for rows.Next() {
+		var duplicateID, hotelID string
+		if err := rows.Scan(&duplicateID, &hotelID); err != nil {
+			return nil, fmt.Errorf("failed to get approved duplicates: %w", err)
+		}
+		approved[duplicateID] = hotelID
+	}
+
+	return approved, rows.Err()
+}
+
+func (r *SQLiteRepository) GetRejectedDuplicates(ctx context.Context) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT pair_key FROM rejected_duplicates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rejected duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	rejected := make(map[string]bool)
+	for rows.Next() {
+		var pair string
+		if err := rows.Scan(&pair); err != nil {
+			return nil, fmt.Errorf("failed to get rejected duplicates: %w", err)
+		}
+		rejected[pair] = true
+	}
+
+	return rejected, rows.Err()
+}
+
+func (r *SQLiteRepository) GetCrosswalkEntries(ctx context.Context) ([]domain.CrosswalkEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT supplier, kind, native_id, canonical_id FROM crosswalk_entries
+		ORDER BY supplier, kind, native_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crosswalk entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.CrosswalkEntry{}
+	for rows.Next() {
+		var entry domain.CrosswalkEntry
+		if err := rows.Scan(&entry.Supplier, &entry.Kind, &entry.NativeID, &entry.CanonicalID); err != nil {
+			return nil, fmt.Errorf("failed to get crosswalk entries: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *SQLiteRepository) StoreCrosswalkEntry(ctx context.Context, entry domain.CrosswalkEntry) error {
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO crosswalk_entries (supplier, kind, native_id, canonical_id) VALUES (?, ?, ?, ?)
+		ON CONFLICT (supplier, kind, native_id) DO UPDATE SET canonical_id = excluded.canonical_id`,
+		entry.Supplier, entry.Kind, entry.NativeID, entry.CanonicalID); err != nil {
+		return fmt.Errorf("failed to store crosswalk entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) DeleteCrosswalkEntry(ctx context.Context, supplier, kind, nativeID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM crosswalk_entries WHERE supplier = ? AND kind = ? AND native_id = ?`,
+		supplier, kind, nativeID); err != nil {
+		return fmt.Errorf("failed to delete crosswalk entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) StoreCrosswalkReport(ctx context.Context, report domain.CrosswalkReport) error {
+	if err := r.storeReport(ctx, reportCrosswalk, report); err != nil {
+		return fmt.Errorf("failed to store crosswalk report: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetCrosswalkReport(ctx context.Context) (*domain.CrosswalkReport, error) {
+	var report domain.CrosswalkReport
+	found, err := r.getReport(ctx, reportCrosswalk, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crosswalk report: %w", err)
+	}
+	if !found {
+		return &domain.CrosswalkReport{Unmapped: map[string]domain.UnmappedIDs{}}, nil
+	}
+	return &report, nil
+}
+
+func (r *SQLiteRepository) StoreDestinations(ctx context.Context, destinations []*domain.Destination) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM destinations`); err != nil {
+			return err
+		}
+		expiresAt := r.expiresAt(r.ttl.Destinations)
+		for _, destination := range destinations {
+			data, err := json.Marshal(destination)
+			if err != nil {
+				return fmt.Errorf("failed to marshal destination %d: %w", destination.DestinationID, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO destinations (destination_id, data, expires_at) VALUES (?, ?, ?)`,
+				destination.DestinationID, data, expiresAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store destinations: %w", err)
+	}
+
+	log.Printf("Stored %d destinations", len(destinations))
+	return nil
+}
+
+func (r *SQLiteRepository) GetDestinationByID(ctx context.Context, destinationID int) (*domain.Destination, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM destinations WHERE destination_id = ? AND expires_at > ?`,
+		destinationID, time.Now().Unix()).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("destination not found: %d", destinationID)
+		}
+		return nil, fmt.Errorf("failed to get destination: %w", err)
+	}
+
+	var destination domain.Destination
+	if err := json.Unmarshal(data, &destination); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal destination: %w", err)
+	}
+
+	return &destination, nil
+}
+
+func (r *SQLiteRepository) GetAllDestinations(ctx context.Context) ([]*domain.Destination, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT destination_id, data FROM destinations WHERE expires_at > ? ORDER BY destination_id`,
+		time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destinations: %w", err)
+	}
+	defer rows.Close()
+
+	destinations := []*domain.Destination{}
+	for rows.Next() {
+		var destinationID int
+		var data []byte
+		if err := rows.Scan(&destinationID, &data); err != nil {
+			return nil, fmt.Errorf("failed to get destinations: %w", err)
+		}
+
+		var destination domain.Destination
+		if err := json.Unmarshal(data, &destination); err != nil {
+			log.Printf("Failed to unmarshal destination %d: %v", destinationID, err)
+			continue
+		}
+		destinations = append(destinations, &destination)
+	}
+
+	return destinations, rows.Err()
+}
+
+func (r *SQLiteRepository) StoreQualityReport(ctx context.Context, report domain.QualityReport) error {
+	if err := r.storeReport(ctx, reportQuality, report); err != nil {
+		return fmt.Errorf("failed to store quality report: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetQualityReport(ctx context.Context) (*domain.QualityReport, error) {
+	var report domain.QualityReport
+	found, err := r.getReport(ctx, reportQuality, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quality report: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("quality report not found")
+	}
+	return &report, nil
+}
+
+// AppendHotelVersion numbers the version from the newest stored one inside
+// its transaction, which takes the write lock when it begins.
+func (r *SQLiteRepository) AppendHotelVersion(ctx context.Context, version domain.HotelVersion, maxVersions int) (int, error) {
+	version.ExpiresAt = versionExpiry(r.ttl.Hotels)
+
+	appended := 0
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		appended, err = r.appendHotelVersion(ctx, tx, version.Hotel.HotelID, version, maxVersions)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store hotel version: %w", err)
+	}
+
+	return appended, nil
+}
+
+// appendHotelVersion records version in the hotel's history within tx,
+// keeping at most maxVersions; zero keeps them all.
+func (r *SQLiteRepository) appendHotelVersion(ctx context.Context, tx *sql.Tx, hotelID string, version domain.HotelVersion, maxVersions int) (int, error) {
+	var latest *domain.HotelVersion
+	var latestData []byte
+	err := tx.QueryRowContext(ctx, `SELECT data FROM hotel_history WHERE hotel_id = ? ORDER BY version DESC LIMIT 1`,
+		hotelID).Scan(&latestData)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == nil {
+		latest = &domain.HotelVersion{}
+		if _, err := decodeRecord(latestData, latest, versionedHotel); err != nil {
+			return 0, fmt.Errorf("failed to decode latest version: %w", err)
+		}
+	}
+
+	number, refresh := domain.NextHotelVersion(latest, version, time.Now())
+	if refresh {
+		latest.ExpiresAt = version.ExpiresAt
+		data, err := encodeRecord(latest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal hotel version: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE hotel_history SET data = ? WHERE hotel_id = ? AND version = ?`,
+			data, hotelID, latest.Version); err != nil {
+			return 0, err
+		}
+	}
+	if number == 0 {
+		return 0, nil
+	}
+
+	version.Version = number
+	data, err := encodeRecord(version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal hotel version: %w", err)
+	}
+	var destinationID interface{}
+	if version.Hotel != nil {
+		destinationID = version.Hotel.DestinationID
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO hotel_history (hotel_id, version, recorded_at, destination_id, data)
+		VALUES (?, ?, ?, ?, ?)`, hotelID, number, version.RecordedAt.UnixNano(), destinationID, data); err != nil {
+		return 0, err
+	}
+	if maxVersions > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM hotel_history WHERE hotel_id = ? AND version NOT IN (
+			SELECT version FROM hotel_history WHERE hotel_id = ? ORDER BY version DESC LIMIT ?)`,
+			hotelID, hotelID, maxVersions); err != nil {
+			return 0, err
+		}
+	}
+
+	return number, nil
+}
+
+// buryHotel records within tx that a hotel was deleted. Tombstones are never
+// trimmed on their own; the next append does.
+func (r *SQLiteRepository) buryHotel(ctx context.Context, tx *sql.Tx, hotelID string) error {
+	if _, err := r.appendHotelVersion(ctx, tx, hotelID, domain.NewTombstone(time.Now()), 0); err != nil {
+		return fmt.Errorf("failed to record deletion of hotel %s: %w", hotelID, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) GetHotelHistory(ctx context.Context, hotelID string) ([]domain.HotelVersion, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT data FROM hotel_history WHERE hotel_id = ? ORDER BY version DESC`, hotelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []domain.HotelVersion{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to get hotel history: %w", err)
+		}
+
+		var version domain.HotelVersion
+		if _, err := decodeRecord(data, &version, versionedHotel); err != nil {
+			log.Printf("Failed to decode version of hotel %s: %v", hotelID, err)
+			continue
+		}
+		history = append(history, version)
+	}
+
+	return history, rows.Err()
+}
+
+func (r *SQLiteRepository) GetDestinationHistoryHotelIDs(ctx context.Context, destinationID int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT hotel_id FROM hotel_history WHERE destination_id = ?`, destinationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hotel history IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var hotelIDs []string
+	for rows.Next() {
+		var hotelID string
+		if err := rows.Scan(&hotelID); err != nil {
+			return nil, fmt.Errorf("failed to get hotel history IDs: %w", err)
+		}
+		hotelIDs = append(hotelIDs, hotelID)
+	}
+
+	return hotelIDs, rows.Err()
+}
+
+// MigrateRecords rewrites hotel and history rows older than
+// HotelSchemaVersion in the current format.
+func (r *SQLiteRepository) MigrateRecords(ctx context.Context) (MigrationReport, error) {
+	var report MigrationReport
+
+	tables := []struct {
+		name   string
+		key    string
+		target func() interface{}
+		hotels func(payload interface{}) []interface{}
+	}{
+		{"hotels", "hotel_id", func() interface{} { return &domain.Hotel{} }, singleHotel},
+		{"hotel_history", "hotel_id || ':' || version", func() interface{} { return &domain.HotelVersion{} }, versionedHotel},
+	}
+
+	for _, table := range tables {
+		rows, err := r.db.QueryContext(ctx, `SELECT rowid, `+table.key+`, data FROM `+table.name)
+		if err != nil {
+			return report, fmt.Errorf("failed to read %s: %w", table.name, err)
+		}
+
+		updates := make(map[int64][]byte)
+		for rows.Next() {
+			var rowID int64
+			var key string
+			var data []byte
+			if err := rows.Scan(&rowID, &key, &data); err != nil {
+				rows.Close()
+				return report, fmt.Errorf("failed to read %s: %w", table.name, err)
+			}
+			report.Scanned++
+
+			target := table.target()
+			migrated, err := decodeRecord(data, target, table.hotels)
+			if err != nil {
+				log.Printf("Failed to decode %s %s: %v", table.name, key, err)
+				report.Failed++
+				continue
+			}
+			if !migrated {
+				continue
+			}
+
+			encoded, err := encodeRecord(target)
+			if err != nil {
+				log.Printf("Failed to encode %s %s: %v", table.name, key, err)
+				report.Failed++
+				continue
+			}
+			updates[rowID] = encoded
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, fmt.Errorf("failed to read %s: %w", table.name, err)
+		}
+
+		err = r.withTx(ctx, func(tx *sql.Tx) error {
+			for rowID, data := range updates {
+				if _, err := tx.ExecContext(ctx, `UPDATE `+table.name+` SET data = ? WHERE rowid = ?`, data, rowID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to rewrite %s: %w", table.name, err)
+		}
+		report.Migrated += len(updates)
+	}
+
+	return report, nil
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
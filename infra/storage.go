@@ -0,0 +1,72 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hotelsdatapipeline/domain"
+)
+
+const (
+	StorageRedis    = "redis"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+// TTLPolicy is how long each kind of stored record lives. A zero duration
+// keeps records until they are overwritten or deleted.
+type TTLPolicy struct {
+	Hotels       time.Duration
+	Destinations time.Duration
+	Reports      time.Duration
+}
+
+// neverExpires is the expiry written by backends that store one for every
+// row when the policy keeps records.
+var neverExpires = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+func expiryAfter(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return neverExpires
+	}
+	return time.Now().Add(ttl)
+}
+
+// versionExpiry is the ExpiresAt of a hotel version stored now under ttl.
+func versionExpiry(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(ttl)
+	return &expiresAt
+}
+
+// Storage is a repository backend the pipeline can run on.
+type Storage interface {
+	domain.Repository
+	Close() error
+}
+
+// Migrator is implemented by backends that can rewrite stored records in the
+// current schema version.
+type Migrator interface {
+	MigrateRecords(ctx context.Context) (MigrationReport, error)
+}
+
+func NewStorage(config *Config) (Storage, error) {
+	ttl := config.TTL.Policy()
+	switch config.Storage.Type {
+	case StorageRedis:
+		return NewRedisRepository(config.Redis, ttl)
+	case StorageMemory:
+		return NewMemoryRepository(config.Storage.Memory.SnapshotFile, ttl)
+	case StorageSQLite:
+		return NewSQLiteRepository(config.Storage.SQLite, ttl)
+	case StoragePostgres:
+		return NewPostgresRepository(config.Storage.Postgres, ttl)
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", config.Storage.Type)
+	}
+}
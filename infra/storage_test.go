@@ -0,0 +1,106 @@
+package infra
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// forEachStorage runs fn against a fresh repository of every backend that can
+// run here: memory and SQLite always, Redis when REDIS_TEST_ADDR (host:port)
+// is set and PostgreSQL when POSTGRES_TEST_DSN is set. The Redis namespace and
+// the PostgreSQL tables are emptied first, so point them at throwaway servers.
+func forEachStorage(t *testing.T, ttl TTLPolicy, fn func(t *testing.T, storage Storage)) {
+	t.Helper()
+
+	t.Run(StorageMemory, func(t *testing.T) {
+		storage, err := NewMemoryRepository("", ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, storage)
+	})
+
+	t.Run(StorageSQLite, func(t *testing.T) {
+		storage, err := NewSQLiteRepository(SQLiteStorageConfig{
+			Path:      filepath.Join(t.TempDir(), "hotels.db"),
+			BatchSize: 2,
+		}, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { storage.Close() })
+		fn(t, storage)
+	})
+
+	t.Run(StorageRedis, func(t *testing.T) {
+		fn(t, newRedisTestRepository(t, ttl))
+	})
+
+	t.Run(StoragePostgres, func(t *testing.T) {
+		fn(t, newPostgresTestRepository(t, ttl, 2))
+	})
+}
+
+// newRedisTestRepository connects to REDIS_TEST_ADDR, skipping the test when
+// it is not set, and empties the test namespace before and after.
+func newRedisTestRepository(t *testing.T, ttl TTLPolicy) *RedisRepository {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRedisRepository(RedisConfig{
+		Namespace: "hotelsdatapipeline-test",
+		Encoding:  EncodingJSON,
+		BatchSize: 2,
+		Host:      host,
+		Port:      port,
+	}, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.PurgeNamespace(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.PurgeNamespace(context.Background())
+		repo.Close()
+	})
+	return repo
+}
+
+// newPostgresTestRepository connects to POSTGRES_TEST_DSN, skipping the test
+// when it is not set, and empties every table first.
+func newPostgresTestRepository(t *testing.T, ttl TTLPolicy, batchSize int) *PostgresRepository {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	repo, err := NewPostgresRepository(PostgresStorageConfig{DSN: dsn, BatchSize: batchSize}, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.db.Exec(`TRUNCATE hotels, destinations, hotel_history, approved_duplicates,
+		rejected_duplicates, crosswalk_entries, reports`); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"hotelsdatapipeline/application"
+	"hotelsdatapipeline/domain"
 	"hotelsdatapipeline/infra"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,25 +15,142 @@ import (
 
 func main() {
 	log.Println("Starting Hotels Data Pipeline...")
+	// Startup work such as the initial fetch runs under this context, so a
+	// signal interrupts it instead of waiting for it to finish.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 	config, err := infra.LoadConfig("config/test.yaml")
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 	log.Println("Configuration loaded successfully")
-	redisRepo, err := infra.NewRedisRepository(config.Redis.Host, config.Redis.Port, config.Redis.DB)
+	repository, err := infra.NewStorage(config)
 	if err != nil {
-		log.Fatalf("Failed to initialize Redis repository: %v", err)
+		log.Fatalf("Failed to initialize %s repository: %v", config.Storage.Type, err)
+	}
+	defer repository.Close()
+	log.Printf("%s repository initialized successfully", config.Storage.Type)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrator, ok := repository.(infra.Migrator)
+		if !ok {
+			log.Fatalf("Storage type %s does not support migrate", config.Storage.Type)
+		}
+		report, err := migrator.MigrateRecords(ctx)
+		if err != nil {
+			log.Fatalf("Failed to migrate stored records: %v", err)
+		}
+		log.Printf("Migration completed: %d scanned, %d migrated, %d failed", report.Scanned, report.Migrated, report.Failed)
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "benchmark-encodings" {
+		hotels, err := repository.GetAllHotels(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load hotels: %v", err)
+		}
+		results, err := infra.BenchmarkEncodings(hotels, 20)
+		if err != nil {
+			log.Fatalf("Failed to benchmark encodings: %v", err)
+		}
+		fmt.Printf("%-10s %12s %8s %12s %12s\n", "encoding", "bytes", "ratio", "encode/op", "decode/op")
+		for _, result := range results {
+			ratio := float64(result.Bytes) / float64(results[0].Bytes)
+			fmt.Printf("%-10s %12d %8.2f %12s %12s\n", result.Encoding, result.Bytes, ratio, result.Encode, result.Decode)
+		}
+		log.Printf("Benchmarked %d hotels", len(hotels))
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "namespace" {
+		redisRepository, ok := repository.(*infra.RedisRepository)
+		if !ok {
+			log.Fatalf("Storage type %s does not support namespaces", config.Storage.Type)
+		}
+		if len(os.Args) > 2 && os.Args[2] == "list" {
+			keys, err := redisRepository.NamespaceKeys(ctx)
+			if err != nil {
+				log.Fatalf("Failed to list namespace: %v", err)
+			}
+			for _, key := range keys {
+				fmt.Println(key)
+			}
+			log.Printf("Namespace %s has %d keys", config.Redis.Namespace, len(keys))
+			return
+		}
+		// Purging asks for the namespace again so a wrong config file cannot
+		// wipe another environment.
+		if len(os.Args) > 3 && os.Args[2] == "purge" {
+			if os.Args[3] != config.Redis.Namespace {
+				log.Fatalf("Namespace %q does not match the configured namespace %q", os.Args[3], config.Redis.Namespace)
+			}
+			deleted, err := redisRepository.PurgeNamespace(ctx)
+			if err != nil {
+				log.Fatalf("Failed to purge namespace: %v", err)
+			}
+			log.Printf("Purge completed: %d keys deleted", deleted)
+			return
+		}
+		log.Fatalf("Usage: %s namespace list | namespace purge <namespace>", os.Args[0])
+	}
+	if config.Crosswalk.File != "" {
+		// The file only seeds an empty store, so edits made through the admin
+		// endpoints survive restarts.
+		existing, err := repository.GetCrosswalkEntries(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load stored crosswalk: %v", err)
+		}
+		if len(existing) == 0 {
+			entries, err := infra.LoadCrosswalkCSV(config.Crosswalk.File)
+			if err != nil {
+				log.Fatalf("Failed to load crosswalk: %v", err)
+			}
+			for _, entry := range entries {
+				if err := repository.StoreCrosswalkEntry(ctx, entry); err != nil {
+					log.Fatalf("Failed to seed crosswalk: %v", err)
+				}
+			}
+			log.Printf("Seeded %d crosswalk entries from %s", len(entries), config.Crosswalk.File)
+		} else {
+			log.Printf("Using %d stored crosswalk entries, %s not loaded", len(existing), config.Crosswalk.File)
+		}
+	}
+	hotelFetcher := application.NewHotelFetcher(repository, config.Hotels.URLs)
+	if config.Ratings.MergeRule != "" {
+		hotelFetcher.SetRatingMergeRule(config.Ratings.MergeRule)
+	}
+	hotelFetcher.SetReviewScales(config.Ratings.ReviewScales)
+	hotelFetcher.SetNearDuplicateThreshold(config.Dedupe.SimilarityThreshold)
+	hotelFetcher.SetQualityModel(config.Quality.Model())
+	if config.History.MaxVersions > 0 {
+		hotelFetcher.SetMaxVersions(config.History.MaxVersions)
+	}
+	hotelFetcher.SetReconcile(config.TTL.Reconcile)
+	if config.Destinations.File != "" {
+		destinations, err := infra.LoadDestinationsFile(config.Destinations.File)
+		if err != nil {
+			log.Fatalf("Failed to load destinations: %v", err)
+		}
+		hotelFetcher.SetDestinationReference(destinations)
+		log.Printf("Loaded %d reference destinations from %s", len(destinations), config.Destinations.File)
+	}
+	if config.Matching.Threshold > 0 {
+		hotelFetcher.SetDuplicateMatchThreshold(config.Matching.Threshold)
 	}
-	defer redisRepo.Close()
-	log.Println("Redis repository initialized successfully")
-	hotelFetcher := application.NewHotelFetcher(redisRepo, config.Hotels.URLs)
 	log.Println("Hotel fetcher service created")
+	if config.Images.HealthCheck.Enabled {
+		imageChecker := application.NewImageHealthChecker(
+			&http.Client{Timeout: config.Images.HealthCheck.Timeout},
+			config.Images.HealthCheck.Concurrency,
+			config.Images.HealthCheck.CacheTTL,
+		)
+		hotelFetcher.SetImageHealthChecker(imageChecker)
+		log.Println("Image health checker created")
+	}
 	cronService := application.NewCronJobService(hotelFetcher, config.CronJob.Interval)
 	log.Println("Cron job service created")
-	httpServer := application.NewHTTPServer(config.HTTP.Host, config.HTTP.Port, redisRepo)
+	locales := domain.NewLocaleSettings(config.Locales.Default, config.Locales.Fallbacks)
+	httpServer := application.NewHTTPServer(config.HTTP.Host, config.HTTP.Port, repository, locales, config.HTTP.AdminToken)
 	log.Printf("HTTP server created on %s", httpServer.GetAddress())
 	log.Println("Running initial hotel data fetch...")
-	if err := hotelFetcher.FetchAndProcess(); err != nil {
+	if err := hotelFetcher.FetchAndProcess(ctx); err != nil {
 		log.Printf("Initial hotel fetch failed: %v", err)
 	} else {
 		log.Println("Initial hotel fetch completed successfully")
@@ -38,6 +159,12 @@ func main() {
 		log.Fatalf("Failed to start cron service: %v", err)
 	}
 	log.Println("Cron scheduler started")
+	if config.Images.HealthCheck.Enabled {
+		if err := cronService.ScheduleImageHealthCheck(config.Images.HealthCheck.Interval); err != nil {
+			log.Fatalf("Failed to schedule image health check: %v", err)
+		}
+		log.Println("Image health check scheduled")
+	}
 	go func() {
 		if err := httpServer.Start(); err != nil {
 			log.Fatalf("Failed to start HTTP server: %v", err)
@@ -45,16 +172,16 @@ func main() {
 	}()
 	log.Printf("Hotels Data Pipeline is running on %s", httpServer.GetAddress())
 	log.Println("Press Ctrl+C to stop the application")
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	<-ctx.Done()
+	// A second signal stops the process without waiting for the shutdown.
+	stop()
 	log.Println("Shutdown signal received, starting graceful shutdown...")
 	if err := httpServer.Stop(); err != nil {
 		log.Printf("Error stopping HTTP server: %v", err)
 	}
 	cronService.Stop()
-	if err := redisRepo.Close(); err != nil {
-		log.Printf("Error closing Redis connection: %v", err)
+	if err := repository.Close(); err != nil {
+		log.Printf("Error closing %s repository: %v", config.Storage.Type, err)
 	}
 	log.Println("Hotels Data Pipeline shutdown completed")
 }